@@ -1,6 +1,12 @@
 package impl
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/Bios-Marcel/wastebasket/v2"
 	"github.com/spf13/cobra"
 )
@@ -8,13 +14,112 @@ import (
 var EmptyCmd = &cobra.Command{
 	Use:   "empty",
 	Short: "empty clears all trashbins that can be found",
+	Example: `
+  wastebasket empty
+  # Only clear the trashbin in your home directory.
+  wastebasket empty --home-only
+  # Only clear the trashbin of a specific mount.
+  wastebasket empty --mount /mnt/data
+  # Only delete log files trashed more than a month ago.
+  wastebasket empty --match '*.log' --older-than 30d
+`,
 	// If used as root cmd, these will be ignored.
 	SuggestFor: []string{"clear"},
-	// Currently none, as empty just clears every trashbin it can find.
+	// Files are selected via flags instead.
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := wastebasket.Empty(); err != nil {
+		options := wastebasket.EmptyOptions{}
+
+		olderThan, err := cmd.Flags().GetString("older-than")
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+		if olderThan != "" {
+			options.OlderThan, err = parseDuration(olderThan)
+			if err != nil {
+				cmd.PrintErrln(err)
+				return
+			}
+		}
+
+		options.HomeOnly, err = cmd.Flags().GetBool("home-only")
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+
+		options.Mount, err = cmd.Flags().GetString("mount")
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+
+		options.Match, err = cmd.Flags().GetString("match")
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+
+		options.Under, err = cmd.Flags().GetString("under")
+		if err != nil {
 			cmd.PrintErrln(err)
+			return
+		}
+
+		report, err := wastebasket.EmptyWithReport(options)
+		if errors.Is(err, wastebasket.ErrPlatformNotSupported) {
+			// Some platforms can empty the trashbin, but can't tell us
+			// what they deleted.
+			if err := wastebasket.EmptyWithOptions(options); err != nil {
+				cmd.PrintErrln(err)
+			}
+			return
+		}
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+
+		for _, result := range report.Results {
+			fmt.Printf("%s: removed %d entries, freed %d bytes\n", result.TrashDir, result.Removed, result.FreedBytes)
+			for _, failure := range result.Failures {
+				cmd.PrintErrf("\t'%s' %s: %s\n", failure.Path, failure.Reason, failure.Err)
+			}
 		}
+		fmt.Printf("Removed %d entries, freed %d bytes in total\n", report.Removed, report.FreedBytes)
 	},
 }
+
+// parseDuration is the same as time.ParseDuration, but additionally allows
+// days, such as `30d`. Since a zero duration would match every file, only
+// positive durations are allowed.
+func parseDuration(value string) (time.Duration, error) {
+	var duration time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s'", value)
+		}
+		duration = time.Duration(count) * 24 * time.Hour
+	} else {
+		var err error
+		duration, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("duration '%s' must be positive", value)
+	}
+	return duration, nil
+}
+
+func init() {
+	EmptyCmd.Flags().String("older-than", "", "If set, only files trashed longer ago than the given duration are deleted, for example 30d or 12h.")
+	EmptyCmd.Flags().Bool("home-only", false, "If set, only the trashbin in the home directory is cleared.")
+	EmptyCmd.Flags().String("mount", "", "If set, only the trashbins of the given mount point are cleared.")
+	EmptyCmd.Flags().String("match", "", "If set, only files whose original path matches the given glob are deleted.")
+	EmptyCmd.Flags().String("under", "", "If set, only files whose original path is inside of the given directory are deleted.")
+}
@@ -0,0 +1,43 @@
+package impl
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Bios-Marcel/wastebasket/v2"
+	"github.com/spf13/cobra"
+)
+
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list prints all files in the trash",
+	Long:  "list prints all files in all available trashbins, grouped by the trashbin they reside in. Each file is printed line by line.",
+	// If used as root cmd, these will be ignored.
+	SuggestFor: []string{"ls"},
+	Aliases:    []string{"ls"},
+	Args:       cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := wastebasket.List()
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+
+		for _, err := range result.Errors {
+			cmd.PrintErrln(err)
+		}
+
+		trashDirs := make([]string, 0, len(result.Matches))
+		for trashDir := range result.Matches {
+			trashDirs = append(trashDirs, trashDir)
+		}
+		slices.Sort(trashDirs)
+
+		for _, trashDir := range trashDirs {
+			fmt.Printf("%s:\n", trashDir)
+			for _, value := range result.Matches[trashDir] {
+				fmt.Printf("  %s %s\n", value.OriginalPath(), value.DeletionDate())
+			}
+		}
+	},
+}
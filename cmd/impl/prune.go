@@ -0,0 +1,95 @@
+package impl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Bios-Marcel/wastebasket/v2"
+	"github.com/spf13/cobra"
+)
+
+var PruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "prune deletes the oldest files of each trashbin, until it is smaller than the given size",
+	Example: `
+  wastebasket prune --max-size 10G
+  # Only print what would be deleted.
+  wastebasket prune --max-size 500M --dry-run
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		options := wastebasket.PruneOptions{}
+
+		maxSize, err := cmd.Flags().GetString("max-size")
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+		options.MaxTotalBytes, err = parseSize(maxSize)
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+
+		options.DryRun, err = cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+
+		report, err := wastebasket.Prune(options)
+		if err != nil {
+			cmd.PrintErrln(err)
+			// Partial reports are still printed.
+			if report == nil {
+				return
+			}
+		}
+
+		action, summary := "Deleted", "Freed"
+		if options.DryRun {
+			action, summary = "Would delete", "Would free"
+		}
+		for _, result := range report.Results {
+			for _, deleted := range result.Deleted {
+				fmt.Printf("%s '%s' from %s\n", action, deleted.OriginalPath(), deleted.DeletionDate())
+			}
+		}
+		fmt.Printf("%s %d bytes in total\n", summary, report.FreedBytes)
+	},
+}
+
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+}
+
+// parseSize parses a size in bytes, optionally using binary units, such as
+// `10G` or `500M`.
+func parseSize(value string) (int64, error) {
+	number, factor := strings.TrimSuffix(strings.ToUpper(value), "B"), int64(1)
+	for _, unit := range sizeUnits {
+		if trimmed, ok := strings.CutSuffix(number, unit.suffix); ok {
+			number, factor = trimmed, unit.factor
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s'", value)
+	}
+	return size * factor, nil
+}
+
+func init() {
+	PruneCmd.Flags().String("max-size", "", "The maximum size of each trashbin, for example 10G or 500M.")
+	PruneCmd.MarkFlagRequired("max-size")
+	PruneCmd.Flags().Bool("dry-run", false, "If set, files that would be deleted are printed, but not deleted.")
+}
@@ -14,8 +14,7 @@ var QueryCmd = &cobra.Command{
 	// If used as root cmd, these will be ignored.
 	SuggestFor: []string{"lookup"},
 	Aliases:    []string{"lookup"},
-	// Currently none, as empty just clears every trashbin it can find.
-	Args: cobra.ExactArgs(1),
+	Args:       cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		options := wastebasket.QueryOptions{}
 
@@ -25,7 +24,26 @@ var QueryCmd = &cobra.Command{
 			return
 		}
 
-		options.Glob = glob
+		match, err := cmd.Flags().GetString("match")
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+		options.Match, err = parseMatchMode(match)
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+		if glob {
+			options.Match = wastebasket.MatchGlob
+		}
+
+		options.CaseInsensitive, err = cmd.Flags().GetBool("ignore-case")
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+
 		options.Search = args
 
 		result, err := wastebasket.Query(options)
@@ -34,6 +52,10 @@ var QueryCmd = &cobra.Command{
 			return
 		}
 
+		for _, err := range result.Errors {
+			cmd.PrintErrln(err)
+		}
+
 		for _, value := range result.Matches {
 			for _, value := range value {
 				fmt.Printf("%s %s\n", value.OriginalPath(), value.DeletionDate())
@@ -42,6 +64,24 @@ var QueryCmd = &cobra.Command{
 	},
 }
 
+var matchModes = map[string]wastebasket.MatchMode{
+	"exact":     wastebasket.MatchExact,
+	"glob":      wastebasket.MatchGlob,
+	"regexp":    wastebasket.MatchRegexp,
+	"substring": wastebasket.MatchSubstring,
+	"basename":  wastebasket.MatchBasename,
+}
+
+func parseMatchMode(value string) (wastebasket.MatchMode, error) {
+	mode, ok := matchModes[value]
+	if !ok {
+		return 0, fmt.Errorf("unknown match mode '%s'", value)
+	}
+	return mode, nil
+}
+
 func init() {
-	QueryCmd.Flags().Bool("glob", false, "If set, the given paths will be treated as globs instead of normal paths.")
+	QueryCmd.Flags().Bool("glob", false, "If set, the given paths will be treated as globs instead of normal paths. Same as --match=glob.")
+	QueryCmd.Flags().String("match", "exact", "Defines how the given arguments are matched against the paths of trashed files. One of exact, glob, regexp, substring or basename.")
+	QueryCmd.Flags().BoolP("ignore-case", "i", false, "If set, casing is ignored when matching.")
 }
@@ -1,9 +1,11 @@
 package impl
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"slices"
+	"path/filepath"
 	"strings"
 
 	"github.com/Bios-Marcel/wastebasket/v2"
@@ -16,7 +18,11 @@ var RestoreCmd = &cobra.Command{
 	Example: `
   wastebasket restore /home/user/document/file.pdf
   # ID for the case that there are multiple versions of the given file.
-  wastebasket restore /home/user/document/file.pdf@5033e67d2c9d
+  wastebasket restore /home/user/document/file.pdf@L2hvbWUvdXNlci8ubG9jYWwvc2hhcmUvVHJhc2gvaW5mby9maWxlLnBkZi50cmFzaGluZm8
+  # Restore a copy next to the current version of the file.
+  wastebasket restore /home/user/document/file.pdf --to /home/user/document/file_old.pdf
+  # Restore a single file out of the trashed directory /home/user/project.
+  wastebasket restore /home/user/project/src/main.go
 `,
 	// If used as root cmd, these will be ignored.
 	SuggestFor: []string{"recover"},
@@ -33,24 +39,41 @@ var RestoreCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		var id string
-		arg := args[0]
-		indexOfAt := strings.LastIndexByte(arg, '@')
-		// 17 = identifiersize - 1.
-		if indexOfAt != -1 && indexOfAt == len(arg)-17 {
-			id = string(arg[indexOfAt+1:])
-			options.Search = []string{arg[:indexOfAt]}
-		} else {
-			options.Search = args
+		restoreOptions := wastebasket.RestoreOptions{}
+		restoreOptions.Force, err = cmd.Flags().GetBool("force")
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
 		}
-		arg = options.Search[0]
 
-		force, err := cmd.Flags().GetBool("force")
+		onConflict, err := cmd.Flags().GetString("on-conflict")
 		if err != nil {
 			cmd.PrintErrln(err)
 			os.Exit(1)
 		}
+		policy, ok := conflictPolicies[onConflict]
+		if !ok {
+			cmd.PrintErrf("unknown conflict policy '%s'\n", onConflict)
+			os.Exit(1)
+		}
+		restoreOptions.OnConflict = policy
 
+		parents, err := cmd.Flags().GetBool("parents")
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		restoreOptions.RestoreParents = parents
+		restoreOptions.CreateParents = parents
+
+		to, err := cmd.Flags().GetString("to")
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+
+		arg := args[0]
+		options.Search = args
 		result, err := wastebasket.Query(options)
 		if err != nil {
 			cmd.PrintErrln(err)
@@ -58,11 +81,42 @@ var RestoreCmd = &cobra.Command{
 		}
 
 		matches := result.Matches[arg]
-		if id != "" {
-			matches = slices.DeleteFunc(matches,
-				func(item wastebasket.TrashedFileInfo) bool {
-					return item.UniqueIdentifier() != id
-				})
+		// Since @ is valid in paths, the suffix is only treated as an ID, if
+		// the whole argument isn't a trashed file.
+		if indexOfAt := strings.LastIndexByte(arg, '@'); indexOfAt != -1 && len(matches) == 0 && !options.Glob {
+			match, err := lookupPathWithID(arg[:indexOfAt], arg[indexOfAt+1:])
+			if err != nil {
+				cmd.PrintErrf("error looking up '%s':\n\t%s\n", arg, err)
+				os.Exit(1)
+			}
+
+			dest := to
+			if dest == "" {
+				dest = match.OriginalPath()
+			}
+			cmd.Printf("Restoring '%s' from '%s' ...\n", dest, match.DeletionDate())
+			restoreTo(cmd, arg, match, dest, restoreOptions)
+			return
+		}
+		if len(matches) == 0 && !options.Glob {
+			// The file might be part of a trashed directory.
+			ancestor, relative, err := findTrashedAncestor(arg)
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			if ancestor != nil {
+				dest := to
+				if dest == "" {
+					dest = filepath.Join(ancestor.OriginalPath(), relative)
+				}
+				cmd.Printf("Restoring '%s' from trashed directory '%s' ...\n", dest, ancestor.OriginalPath())
+				if err := ancestor.RestorePath(relative, dest); err != nil {
+					cmd.PrintErrf("error restoring '%s':\n\t%s\n", arg, err)
+					os.Exit(1)
+				}
+				return
+			}
 		}
 
 		if len(matches) == 0 {
@@ -71,14 +125,22 @@ var RestoreCmd = &cobra.Command{
 		}
 
 		if len(matches) == 1 {
-			cmd.Printf("Restoring '%s' ...\n", matches[0].OriginalPath())
-			if err := matches[0].Restore(force); err != nil {
-				cmd.PrintErrf("error restoring '%s':\n\t%s\n", arg, err)
-				os.Exit(1)
+			if to != "" {
+				cmd.Printf("Restoring '%s' to '%s' ...\n", matches[0].OriginalPath(), to)
+				restoreTo(cmd, arg, matches[0], to, restoreOptions)
+				return
 			}
+
+			cmd.Printf("Restoring '%s' ...\n", matches[0].OriginalPath())
+			restoreTo(cmd, arg, matches[0], matches[0].OriginalPath(), restoreOptions)
 			return
 		}
 
+		if to != "" {
+			cmd.PrintErrf("Can't restore multiple files to '%s'; %d matches found for '%s'\n", to, len(matches), arg)
+			os.Exit(1)
+		}
+
 		dedupe := make(map[string][]wastebasket.TrashedFileInfo)
 		for _, match := range matches {
 			dedupe[match.OriginalPath()] = append(dedupe[match.OriginalPath()], match)
@@ -89,10 +151,7 @@ var RestoreCmd = &cobra.Command{
 				match := arr[0]
 				fmt.Printf("Restoring '%s' from '%s'\n",
 					match.OriginalPath(), match.DeletionDate())
-				if err := match.Restore(force); err != nil {
-					cmd.PrintErrf("Error restoring '%s': %s\n", match.OriginalPath(), err)
-					os.Exit(1)
-				}
+				restoreTo(cmd, match.OriginalPath(), match, match.OriginalPath(), restoreOptions)
 			}
 		}
 		for _, arr := range dedupe {
@@ -107,7 +166,96 @@ var RestoreCmd = &cobra.Command{
 	},
 }
 
+// lookupPathWithID looks up a trashed file by its ID and makes sure that it
+// has been trashed from the given path, so a wrong ID can't restore an
+// unrelated file.
+func lookupPathWithID(path, id string) (wastebasket.TrashedFileInfo, error) {
+	match, err := wastebasket.Lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if match.OriginalPath() != absPath {
+		return nil, fmt.Errorf("ID belongs to '%s' instead of '%s'", match.OriginalPath(), absPath)
+	}
+	return match, nil
+}
+
+// restoreTo restores the match to dest and exits on failure. Files left in
+// the trash due to the conflict policy are reported, but aren't a failure.
+func restoreTo(cmd *cobra.Command, arg string, match wastebasket.TrashedFileInfo, dest string, options wastebasket.RestoreOptions) {
+	err := match.RestoreTo(dest, options)
+	if errors.Is(err, wastebasket.ErrSkipped) {
+		cmd.Printf("Skipped '%s', as '%s' already exists\n", arg, dest)
+		return
+	}
+	if err != nil {
+		cmd.PrintErrf("error restoring '%s':\n\t%s\n", arg, err)
+		os.Exit(1)
+	}
+}
+
+// findTrashedAncestor looks for a trashed directory containing the given
+// path. If multiple versions of the directory contain the path, the newest
+// one is returned, alongside the path relative to the directory.
+func findTrashedAncestor(path string) (wastebasket.TrashedFileInfo, string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var ancestors []string
+	for dir := filepath.Dir(absPath); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		ancestors = append(ancestors, dir)
+	}
+
+	result, err := wastebasket.Query(wastebasket.QueryOptions{Search: ancestors})
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The closest ancestor is checked first, as a trashed directory could
+	// in turn contain a directory that has been trashed separately.
+	for _, ancestor := range ancestors {
+		relative, err := filepath.Rel(ancestor, absPath)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var newest wastebasket.TrashedFileInfo
+		for _, match := range result.Matches[ancestor] {
+			if _, err := fs.Stat(match.FS(), filepath.ToSlash(relative)); err != nil {
+				continue
+			}
+			if newest == nil || match.DeletionDate().After(newest.DeletionDate()) {
+				newest = match
+			}
+		}
+		if newest != nil {
+			return newest, relative, nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+var conflictPolicies = map[string]wastebasket.ConflictPolicy{
+	"fail":               wastebasket.ConflictFail,
+	"overwrite":          wastebasket.ConflictOverwrite,
+	"skip":               wastebasket.ConflictSkip,
+	"keep-both":          wastebasket.ConflictKeepBoth,
+	"overwrite-if-older": wastebasket.ConflictOverwriteIfOlder,
+	"merge":              wastebasket.ConflictMerge,
+}
+
 func init() {
 	RestoreCmd.Flags().Bool("glob", false, "If set, the given paths will be treated as globs instead of normal paths.")
-	RestoreCmd.Flags().Bool("force", false, "If set, restore will overwrite existing files.")
+	RestoreCmd.Flags().Bool("force", false, "If set, restore will overwrite existing files. Same as --on-conflict=overwrite.")
+	RestoreCmd.Flags().String("on-conflict", "fail", "Defines what happens if a file already exists. One of fail, overwrite, skip, keep-both, overwrite-if-older or merge.")
+	RestoreCmd.Flags().Bool("parents", false, "If set, missing parent directories will be restored from the trash or created.")
+	RestoreCmd.Flags().String("to", "", "If set, the file will be restored to the given path instead of its original location. Only a single file can be restored this way.")
 }
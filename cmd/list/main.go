@@ -0,0 +1,13 @@
+package main
+
+import (
+	"os"
+
+	"github.com/Bios-Marcel/wastebasket/v2/cmd/impl"
+)
+
+func main() {
+	if err := impl.ListCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -15,7 +15,9 @@ func main() {
 	}
 	rootCmd.AddCommand(impl.TrashCmd)
 	rootCmd.AddCommand(impl.EmptyCmd)
+	rootCmd.AddCommand(impl.PruneCmd)
 	rootCmd.AddCommand(impl.QueryCmd)
+	rootCmd.AddCommand(impl.ListCmd)
 	rootCmd.AddCommand(impl.RestoreCmd)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -6,7 +6,6 @@ require (
 	github.com/spf13/cobra v1.8.1
 	github.com/stretchr/testify v1.10.0
 	golang.org/x/sys v0.29.0
-	golang.org/x/text v0.21.0
 )
 
 require (
@@ -0,0 +1,162 @@
+//go:build freebsd || openbsd || netbsd || linux || windows
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CopyAll recursively copies src to dst. dst must not exist yet. Symlinks
+// are copied as symlinks, not followed. File modes, timestamps and, where
+// possible, ownership are preserved. Files other than regular files,
+// directories and symlinks (devices, sockets, pipes) aren't supported.
+func CopyAll(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return fmt.Errorf("error retrieving file info: %w", err)
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return fmt.Errorf("error reading symlink '%s': %w", src, err)
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return fmt.Errorf("error creating symlink '%s': %w", dst, err)
+		}
+	case info.IsDir():
+		// We start with restrictive permissions, the real ones are applied
+		// after the content has been written, as they might make the
+		// directory unwritable.
+		if err := os.Mkdir(dst, 0o700); err != nil {
+			return fmt.Errorf("error creating directory '%s': %w", dst, err)
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return fmt.Errorf("error reading directory '%s': %w", src, err)
+		}
+		for _, entry := range entries {
+			if err := CopyAll(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		if err := os.Chmod(dst, info.Mode()); err != nil {
+			return fmt.Errorf("error setting permissions for '%s': %w", dst, err)
+		}
+	case info.Mode().IsRegular():
+		if err := copyFile(src, dst); err != nil {
+			return err
+		}
+		if err := os.Chmod(dst, info.Mode()); err != nil {
+			return fmt.Errorf("error setting permissions for '%s': %w", dst, err)
+		}
+	default:
+		return fmt.Errorf("error copying '%s': unsupported file type %s", src, info.Mode().Type())
+	}
+
+	// Timestamps have to be applied last, as writing directory content
+	// changes the modification time.
+	return preserveOwnerAndTimes(src, dst, info)
+}
+
+// MoveAll moves src to dst. If both are on different filesystems, src is
+// copied and then removed. If the copy fails, it is removed again, leaving
+// src untouched.
+func MoveAll(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !IsCrossDevice(err) {
+		return err
+	}
+
+	if err := CopyAll(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	if err := VerifyCopy(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("error removing '%s' after copying it: %w", src, err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("error opening '%s': %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_EXCL|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error creating '%s': %w", dst, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("error copying '%s' to '%s': %w", src, dst, err)
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("error closing '%s': %w", dst, err)
+	}
+
+	return nil
+}
+
+// VerifyCopy checks whether dst has the same structure as src. That is, each
+// file exists in both trees, has the same type, regular files have the same
+// size and symlinks point to the same target. This should be called before
+// removing the source of a copy.
+func VerifyCopy(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return fmt.Errorf("error retrieving relative path: %w", err)
+		}
+		copied := filepath.Join(dst, rel)
+
+		srcInfo, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("error retrieving file info: %w", err)
+		}
+		dstInfo, err := os.Lstat(copied)
+		if err != nil {
+			return fmt.Errorf("error verifying copy of '%s': %w", path, err)
+		}
+
+		if srcInfo.Mode().Type() != dstInfo.Mode().Type() {
+			return fmt.Errorf("error verifying copy of '%s': file type differs", path)
+		}
+		if srcInfo.Mode().IsRegular() && srcInfo.Size() != dstInfo.Size() {
+			return fmt.Errorf("error verifying copy of '%s': size differs (%d != %d)", path, srcInfo.Size(), dstInfo.Size())
+		}
+		if srcInfo.Mode()&fs.ModeSymlink != 0 {
+			srcTarget, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("error reading symlink '%s': %w", path, err)
+			}
+			dstTarget, err := os.Readlink(copied)
+			if err != nil {
+				return fmt.Errorf("error reading symlink '%s': %w", copied, err)
+			}
+			if srcTarget != dstTarget {
+				return fmt.Errorf("error verifying copy of '%s': symlink target differs", path)
+			}
+		}
+
+		return nil
+	})
+}
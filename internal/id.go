@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// EncodeID creates the unique identifier of a trashed file from the path of
+// its metadata file. The encoding is reversible and only uses characters
+// that are safe in URLs and file names.
+func EncodeID(infoPath string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(infoPath))
+}
+
+// DecodeID returns the path of the metadata file encoded in the given
+// identifier.
+func DecodeID(id string) (string, error) {
+	infoPath, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return "", fmt.Errorf("error decoding identifier: %w", err)
+	}
+	return string(infoPath), nil
+}
@@ -5,10 +5,12 @@ package internal
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
-	"net/url"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 
 	"golang.org/x/sys/unix"
@@ -83,49 +85,6 @@ func Mounts() ([]string, error) {
 	return mounts, nil
 }
 
-// RemoveAllIfExists tries to remove the given path. The path can either be a
-// directory or a file. This function catches certain errors, so you don't have
-// to.
-func RemoveAllIfExists(path string) error {
-RETRY:
-	if err := os.RemoveAll(path); err != nil {
-		pathErr, ok := err.(*os.PathError)
-		if ok {
-			switch pathErr.Err {
-			case unix.EINTR:
-				// A non-error basically which tells you to try again.
-				// Use goto in order to prevent growing stack.
-				goto RETRY
-			case unix.EACCES:
-				// Missing permissions, so we shouldn't clear it.
-				return nil
-			case unix.ENOENT:
-				// Does not exist.
-				return nil
-			case unix.ENOTDIR:
-				// Not a directory or file. This happens on WSL when
-				// attempting to delete in /mnt/wslg/versions.txt, which is
-				// weird considering that os.Remove (used internally) can
-				// delete files.
-				return nil
-			case unix.EROFS:
-				// Occurs if the filesystem is read-only.
-				return nil
-			}
-		}
-		return fmt.Errorf("error removing file: %w", err)
-	}
-
-	return nil
-}
-
-// EscapeUrl escapes the path according to the FreeDesktop Trash specification.
-// Which basically just refers to "RFC 2396, section 2".
-func EscapeUrl(path string) string {
-	u := &url.URL{Path: path}
-	return u.EscapedPath()
-}
-
 // FileExists omits the parts to make this usable cross-platform and
 // therefore saves a minimal amount of CPU cycles and some allocations.
 func FileExists(path string) (bool, error) {
@@ -153,3 +112,50 @@ RETRY:
 		}
 	}
 }
+
+// IsCrossDevice indicates whether err was caused by attempting to rename a
+// file onto a different filesystem.
+func IsCrossDevice(err error) bool {
+	return errors.Is(err, unix.EXDEV)
+}
+
+// preserveOwnerAndTimes applies the ownership and the access and modification
+// times of src to dst, without following symlinks. Changing the owner is
+// usually only allowed for root, therefore failing to do so is ignored.
+func preserveOwnerAndTimes(src, dst string, _ fs.FileInfo) error {
+	var stat unix.Stat_t
+	if err := unix.Lstat(src, &stat); err != nil {
+		return fmt.Errorf("error retrieving file info: %w", err)
+	}
+
+	_ = unix.Lchown(dst, int(stat.Uid), int(stat.Gid))
+
+	times := []unix.Timespec{stat.Atim, stat.Mtim}
+	if err := unix.UtimesNanoAt(unix.AT_FDCWD, dst, times, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return fmt.Errorf("error setting timestamps for '%s': %w", dst, err)
+	}
+
+	return nil
+}
+
+// DiskUsage calculates the disk space used by path and, in case of a
+// directory, its content. Just like `du -B1`, this counts the allocated
+// blocks, not the apparent file sizes. Symlinks aren't followed.
+func DiskUsage(path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		var stat unix.Stat_t
+		if err := unix.Lstat(path, &stat); err != nil {
+			return fmt.Errorf("error retrieving file info for '%s': %w", path, err)
+		}
+		// Blocks are always 512 bytes, independent of the filesystems
+		// block size.
+		total += int64(stat.Blocks) * 512
+		return nil
+	})
+	return total, err
+}
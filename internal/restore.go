@@ -0,0 +1,32 @@
+package internal
+
+// RestoreOptions is exported by the root package. It is defined here, since
+// the platform specific packages need it as well, but can't import the root
+// package.
+type RestoreOptions struct {
+	// Force is the same as setting OnConflict to ConflictOverwrite.
+	Force bool
+	// OnConflict defines what happens if the destination already exists.
+	// By default, ErrAlreadyExists is returned.
+	OnConflict ConflictPolicy
+	// CreateParents creates all missing parent directories of the
+	// destination. This is done after restoring trashed parents, if
+	// RestoreParents is set as well.
+	CreateParents bool
+	// RestoreParents restores all missing parent directories of the
+	// destination, that are located in the trash. Parents that aren't in the
+	// trash are left missing.
+	RestoreParents bool
+}
+
+// ConflictPolicy is exported by the root package, see RestoreOptions.
+type ConflictPolicy int
+
+const (
+	ConflictFail ConflictPolicy = iota
+	ConflictOverwrite
+	ConflictSkip
+	ConflictKeepBoth
+	ConflictOverwriteIfOlder
+	ConflictMerge
+)
@@ -0,0 +1,33 @@
+//go:build windows
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// IsCrossDevice indicates whether err was caused by attempting to rename a
+// file onto a different volume.
+func IsCrossDevice(err error) bool {
+	return errors.Is(err, windows.ERROR_NOT_SAME_DEVICE)
+}
+
+// preserveOwnerAndTimes applies the modification time of src to dst. Windows
+// doesn't have unix style ownership and changing the timestamps of a symlink
+// would change the timestamps of its target, so both are skipped.
+func preserveOwnerAndTimes(_, dst string, info fs.FileInfo) error {
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return nil
+	}
+
+	if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+		return fmt.Errorf("error setting timestamps for '%s': %w", dst, err)
+	}
+
+	return nil
+}
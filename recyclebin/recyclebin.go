@@ -0,0 +1,162 @@
+// Package recyclebin reads and writes the $I files of the Windows recycle
+// bin. Each trashed file $R... is accompanied by a $I... file, holding the
+// original path, size and deletion date. This package doesn't depend on the
+// platform, so it can also be used to inspect recycle bins copied from other
+// machines, for example forensic images.
+//
+// The files have the following structure, all numbers being little endian:
+//
+//	8 byte  version (1 for Windows Vista to 8.1, 2 for Windows 10 and newer)
+//	8 byte  size of the trashed file or directory
+//	8 byte  deletion date as FILETIME
+//	Version 1:
+//	  520 byte  null terminated and padded UTF-16 path (260 characters)
+//	Version 2:
+//	  4 byte  path length in UTF-16 characters, including null terminator
+//	  N byte  null terminated UTF-16 path
+package recyclebin
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	// Version1 is used by Windows Vista up to Windows 8.1.
+	Version1 uint64 = 1
+	// Version2 is used since Windows 10.
+	Version2 uint64 = 2
+)
+
+// v1PathLength is the fixed path length of Version1 in UTF-16 characters,
+// which equals MAX_PATH.
+const v1PathLength = 260
+
+// filetimeEpochOffset is the difference between the FILETIME epoch
+// (1601-01-01) and the unix epoch in 100 nanosecond intervals.
+const filetimeEpochOffset = 116444736000000000
+
+var (
+	// ErrUnsupportedVersion is returned for $I files with an unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported $I file version")
+	// ErrPathTooLong is returned when writing a Version1 file with a path
+	// exceeding the fixed path length.
+	ErrPathTooLong = errors.New("path too long for version 1")
+)
+
+// Info is the content of a $I file.
+type Info struct {
+	// Version is the format version, either Version1 or Version2.
+	Version uint64
+	// Size is the size of the trashed file in bytes. For directories, this is
+	// the size of all contained files.
+	Size uint64
+	// DeletionDate is the time the file was trashed, in local time.
+	DeletionDate time.Time
+	// Path is the absolute path of the file before it was trashed.
+	Path string
+}
+
+// Parse reads a $I file in either format.
+func Parse(reader io.Reader) (Info, error) {
+	var header struct {
+		Version      uint64
+		Size         uint64
+		DeletionDate uint64
+	}
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return Info{}, fmt.Errorf("error reading header: %w", err)
+	}
+
+	info := Info{
+		Version:      header.Version,
+		Size:         header.Size,
+		DeletionDate: filetimeToTime(header.DeletionDate),
+	}
+
+	var path []uint16
+	switch header.Version {
+	case Version1:
+		path = make([]uint16, v1PathLength)
+	case Version2:
+		var length uint32
+		if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+			return Info{}, fmt.Errorf("error reading path length: %w", err)
+		}
+		// A path can't be longer than 32767 characters on windows, so
+		// anything longer is a broken file and would cause us to allocate
+		// huge amounts of memory.
+		if length > 32768 {
+			return Info{}, fmt.Errorf("invalid path length %d", length)
+		}
+		path = make([]uint16, length)
+	default:
+		return Info{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, header.Version)
+	}
+
+	if err := binary.Read(reader, binary.LittleEndian, path); err != nil {
+		return Info{}, fmt.Errorf("error reading path: %w", err)
+	}
+	for index, char := range path {
+		if char == 0 {
+			path = path[:index]
+			break
+		}
+	}
+	if len(path) == 0 {
+		return Info{}, errors.New("missing path")
+	}
+	info.Path = string(utf16.Decode(path))
+
+	return info, nil
+}
+
+// WriteTo writes the info in the format defined by its Version.
+func (info Info) WriteTo(writer io.Writer) (int64, error) {
+	path := append(utf16.Encode([]rune(info.Path)), 0)
+
+	buffer := make([]byte, 0, 28+2*len(path))
+	buffer = binary.LittleEndian.AppendUint64(buffer, info.Version)
+	buffer = binary.LittleEndian.AppendUint64(buffer, info.Size)
+	buffer = binary.LittleEndian.AppendUint64(buffer, timeToFiletime(info.DeletionDate))
+
+	switch info.Version {
+	case Version1:
+		if len(path) > v1PathLength {
+			return 0, ErrPathTooLong
+		}
+		// The path is padded with null bytes.
+		path = append(path, make([]uint16, v1PathLength-len(path))...)
+	case Version2:
+		buffer = binary.LittleEndian.AppendUint32(buffer, uint32(len(path)))
+	default:
+		return 0, fmt.Errorf("%w: %d", ErrUnsupportedVersion, info.Version)
+	}
+
+	for _, char := range path {
+		buffer = binary.LittleEndian.AppendUint16(buffer, char)
+	}
+
+	written, err := writer.Write(buffer)
+	return int64(written), err
+}
+
+// filetimeTicksPerSecond is the number of 100 nanosecond intervals per second.
+const filetimeTicksPerSecond = 10_000_000
+
+// filetimeToTime converts a FILETIME into a time. Seconds and the remainder
+// are converted separately, as the nanoseconds of damaged or zero FILETIMEs
+// would overflow.
+func filetimeToTime(filetime uint64) time.Time {
+	seconds := int64(filetime/filetimeTicksPerSecond) - filetimeEpochOffset/filetimeTicksPerSecond
+	return time.Unix(seconds, int64(filetime%filetimeTicksPerSecond)*100)
+}
+
+func timeToFiletime(t time.Time) uint64 {
+	seconds := t.Unix() + filetimeEpochOffset/filetimeTicksPerSecond
+	return uint64(seconds)*filetimeTicksPerSecond + uint64(t.Nanosecond()/100)
+}
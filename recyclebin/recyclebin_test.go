@@ -0,0 +1,96 @@
+package recyclebin_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Bios-Marcel/wastebasket/v2/recyclebin"
+)
+
+var fixtures = map[string]recyclebin.Info{
+	// Windows Vista to 8.1
+	"testdata/v1.bin": {
+		Version:      recyclebin.Version1,
+		Size:         12345,
+		DeletionDate: time.Date(2012, 3, 4, 5, 6, 7, 123456000, time.UTC),
+		Path:         `C:\Users\Test\Documents\report.docx`,
+	},
+	// Windows 10 and newer
+	"testdata/v2.bin": {
+		Version:      recyclebin.Version2,
+		Size:         1048576,
+		DeletionDate: time.Date(2024, 10, 11, 12, 13, 14, 500000000, time.UTC),
+		Path:         `D:\Projects\ünïcode folder`,
+	},
+	// Damaged file with a zero FILETIME, as found on forensic images
+	"testdata/zero-date.bin": {
+		Version:      recyclebin.Version2,
+		Size:         42,
+		DeletionDate: time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC),
+		Path:         `C:\damaged.txt`,
+	},
+}
+
+func Test_Parse(t *testing.T) {
+	for fixture, expected := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			require.NoError(t, err)
+
+			info, err := recyclebin.Parse(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, expected.Version, info.Version)
+			assert.Equal(t, expected.Size, info.Size)
+			assert.Equal(t, expected.Path, info.Path)
+			assert.True(t, expected.DeletionDate.Equal(info.DeletionDate), "%s != %s", expected.DeletionDate, info.DeletionDate)
+		})
+	}
+}
+
+func Test_WriteTo(t *testing.T) {
+	for fixture, info := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			expected, err := os.ReadFile(fixture)
+			require.NoError(t, err)
+
+			var buffer bytes.Buffer
+			written, err := info.WriteTo(&buffer)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(expected)), written)
+			assert.Equal(t, expected, buffer.Bytes())
+		})
+	}
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	v2, err := os.ReadFile("testdata/v2.bin")
+	require.NoError(t, err)
+
+	unknownVersion := bytes.Clone(v2)
+	unknownVersion[0] = 3
+	_, err = recyclebin.Parse(bytes.NewReader(unknownVersion))
+	require.ErrorIs(t, err, recyclebin.ErrUnsupportedVersion)
+
+	for _, length := range []int{0, 8, 24, 27, len(v2) - 2} {
+		_, err = recyclebin.Parse(bytes.NewReader(v2[:length]))
+		require.Error(t, err, "length %d", length)
+	}
+}
+
+func Test_WriteTo_PathTooLong(t *testing.T) {
+	info := recyclebin.Info{
+		Version: recyclebin.Version1,
+		Path:    `C:\` + string(bytes.Repeat([]byte("a"), 257)),
+	}
+	_, err := info.WriteTo(&bytes.Buffer{})
+	require.ErrorIs(t, err, recyclebin.ErrPathTooLong)
+
+	info.Version = recyclebin.Version2
+	_, err = info.WriteTo(&bytes.Buffer{})
+	require.NoError(t, err)
+}
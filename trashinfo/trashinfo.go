@@ -0,0 +1,204 @@
+// Package trashinfo reads and writes .trashinfo files, as defined by the
+// FreeDesktop Trash specification. It doesn't depend on the platform, so it
+// can also be used to inspect trash directories from other machines, such as
+// backups.
+package trashinfo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout used for the DeletionDate. It's the same as
+// time.RFC3339, but without a timezone, as the spec demands local time.
+const DateLayout = "2006-01-02T15:04:05"
+
+// deletionDateLayouts are all layouts we accept when parsing the
+// DeletionDate. Some implementations, such as older versions of trash-cli,
+// added a timezone, even though the spec doesn't allow for it. The compact
+// format is used in the example of the spec.
+var deletionDateLayouts = []string{
+	DateLayout,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"20060102T15:04:05",
+}
+
+const groupHeader = "[Trash Info]"
+
+// Info is the content of a .trashinfo file.
+type Info struct {
+	// Path is the unescaped path of the file before it was trashed. It's
+	// either absolute or relative to the directory containing the trash
+	// directory.
+	Path string
+	// DeletionDate is the time the file was trashed.
+	DeletionDate time.Time
+	// Extra contains all other keys of the [Trash Info] group. These are
+	// ignored by the spec, but preserved, so that files can be rewritten
+	// without losing information.
+	Extra map[string]string
+}
+
+// Parse reads a .trashinfo file. The format is that of the desktop entry
+// specification. As required by the trash specification, only the first
+// occurrences of keys in the [Trash Info] group are used and everything else
+// is ignored. To be able to read files written by other implementations, a
+// BOM, CRLF line endings, comments, blank lines and whitespace around the
+// separator are tolerated. Dates without a timezone are interpreted as
+// local time.
+func Parse(reader io.Reader) (Info, error) {
+	var (
+		info                     Info
+		path, deletionDate       string
+		hasPath, hasDeletionDate bool
+		inGroup, sawGroup        bool
+	)
+
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !sawGroup && line != groupHeader {
+				return Info{}, fmt.Errorf("first group must be %s, got %s", groupHeader, line)
+			}
+			inGroup = line == groupHeader && !sawGroup
+			sawGroup = true
+			continue
+		}
+
+		if !sawGroup {
+			return Info{}, fmt.Errorf("line %d: entry outside of %s group", lineNumber, groupHeader)
+		}
+		if !inGroup {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return Info{}, fmt.Errorf("line %d: expected key=value", lineNumber)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case "Path":
+			if !hasPath {
+				path, hasPath = value, true
+			}
+		case "DeletionDate":
+			if !hasDeletionDate {
+				deletionDate, hasDeletionDate = value, true
+			}
+		default:
+			if _, exists := info.Extra[key]; !exists {
+				if info.Extra == nil {
+					info.Extra = make(map[string]string)
+				}
+				info.Extra[key] = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Info{}, fmt.Errorf("error reading .trashinfo file: %w", err)
+	}
+
+	if !hasPath || path == "" {
+		return Info{}, errors.New("missing Path")
+	}
+	if !hasDeletionDate {
+		return Info{}, errors.New("missing DeletionDate")
+	}
+
+	var err error
+	info.DeletionDate, err = ParseDeletionDate(deletionDate)
+	if err != nil {
+		return Info{}, fmt.Errorf("error parsing deletion date: %w", err)
+	}
+
+	info.Path, err = UnescapePath(path)
+	if err != nil {
+		return Info{}, fmt.Errorf("error unescaping path: %w", err)
+	}
+
+	return info, nil
+}
+
+// ParseDeletionDate parses the value of a DeletionDate key. Values without
+// a timezone are interpreted as local time. The result is always in local
+// time.
+func ParseDeletionDate(value string) (time.Time, error) {
+	var firstErr error
+	for _, layout := range deletionDateLayouts {
+		deletionDate, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return deletionDate.Local(), nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, firstErr
+}
+
+// WriteTo writes the info in the .trashinfo format. The DeletionDate is
+// written in local time, without the timezone, as the spec demands and some
+// tools, such as trash-cli, expect. Extra keys are written sorted.
+func (info Info) WriteTo(writer io.Writer) (int64, error) {
+	if info.Path == "" {
+		return 0, errors.New("missing Path")
+	}
+
+	var builder strings.Builder
+	builder.WriteString(groupHeader)
+	builder.WriteString("\nPath=")
+	builder.WriteString(EscapePath(info.Path))
+	builder.WriteString("\nDeletionDate=")
+	builder.WriteString(info.DeletionDate.Local().Format(DateLayout))
+	builder.WriteByte('\n')
+
+	keys := make([]string, 0, len(info.Extra))
+	for key := range info.Extra {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		value := info.Extra[key]
+		if key == "" || strings.ContainsAny(key, "=[#\r\n") || strings.ContainsAny(value, "\r\n") {
+			return 0, fmt.Errorf("invalid extra key '%s'", key)
+		}
+		builder.WriteString(key)
+		builder.WriteByte('=')
+		builder.WriteString(value)
+		builder.WriteByte('\n')
+	}
+
+	written, err := io.WriteString(writer, builder.String())
+	return int64(written), err
+}
+
+// EscapePath escapes the path according to the FreeDesktop Trash
+// specification. Which basically just refers to "RFC 2396, section 2".
+func EscapePath(path string) string {
+	u := &url.URL{Path: path}
+	return u.EscapedPath()
+}
+
+// UnescapePath reverts EscapePath.
+func UnescapePath(path string) (string, error) {
+	return url.PathUnescape(path)
+}
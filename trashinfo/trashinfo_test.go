@@ -0,0 +1,130 @@
+package trashinfo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Bios-Marcel/wastebasket/v2/trashinfo"
+)
+
+func Test_Parse(t *testing.T) {
+	deletionDate := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+
+	cases := []struct {
+		name     string
+		input    string
+		expected trashinfo.Info
+	}{
+		{
+			name:     "plain",
+			input:    "[Trash Info]\nPath=/home/user/file.txt\nDeletionDate=2024-05-06T07:08:09\n",
+			expected: trashinfo.Info{Path: "/home/user/file.txt", DeletionDate: deletionDate},
+		},
+		{
+			name:     "relative path",
+			input:    "[Trash Info]\nPath=file.txt\nDeletionDate=2024-05-06T07:08:09\n",
+			expected: trashinfo.Info{Path: "file.txt", DeletionDate: deletionDate},
+		},
+		{
+			name:     "escaped path",
+			input:    "[Trash Info]\nPath=/my%20file%25%0A.txt\nDeletionDate=2024-05-06T07:08:09\n",
+			expected: trashinfo.Info{Path: "/my file%\n.txt", DeletionDate: deletionDate},
+		},
+		{
+			name:     "no trailing newline",
+			input:    "[Trash Info]\nPath=/file.txt\nDeletionDate=2024-05-06T07:08:09",
+			expected: trashinfo.Info{Path: "/file.txt", DeletionDate: deletionDate},
+		},
+		{
+			name:     "crlf",
+			input:    "[Trash Info]\r\nPath=/file.txt\r\nDeletionDate=2024-05-06T07:08:09\r\n",
+			expected: trashinfo.Info{Path: "/file.txt", DeletionDate: deletionDate},
+		},
+		{
+			name:     "bom",
+			input:    "\uFEFF[Trash Info]\nPath=/file.txt\nDeletionDate=2024-05-06T07:08:09\n",
+			expected: trashinfo.Info{Path: "/file.txt", DeletionDate: deletionDate},
+		},
+		{
+			name:     "reordered keys",
+			input:    "[Trash Info]\nDeletionDate=2024-05-06T07:08:09\nPath=/file.txt\n",
+			expected: trashinfo.Info{Path: "/file.txt", DeletionDate: deletionDate},
+		},
+		{
+			name:     "comments and whitespace",
+			input:    "# Comment\n\n[Trash Info]\n# Comment\nPath = /file.txt\n  DeletionDate=2024-05-06T07:08:09  \n",
+			expected: trashinfo.Info{Path: "/file.txt", DeletionDate: deletionDate},
+		},
+		{
+			name:  "extra keys and groups",
+			input: "[Trash Info]\nPath=/file.txt\nFoo=Bar\nDeletionDate=2024-05-06T07:08:09\nPath=/ignored\nFoo=Ignored\n[Other]\nPath=/ignored\n",
+			expected: trashinfo.Info{
+				Path:         "/file.txt",
+				DeletionDate: deletionDate,
+				Extra:        map[string]string{"Foo": "Bar"},
+			},
+		},
+		{
+			name:     "compact date",
+			input:    "[Trash Info]\nPath=/file.txt\nDeletionDate=20240506T07:08:09\n",
+			expected: trashinfo.Info{Path: "/file.txt", DeletionDate: deletionDate},
+		},
+	}
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			info, err := trashinfo.Parse(strings.NewReader(testCase.input))
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, info)
+		})
+	}
+}
+
+func Test_Parse_Timezone(t *testing.T) {
+	info, err := trashinfo.Parse(strings.NewReader("[Trash Info]\nPath=/file.txt\nDeletionDate=2024-05-06T07:08:09+02:00\n"))
+	require.NoError(t, err)
+	assert.True(t, info.DeletionDate.Equal(time.Date(2024, 5, 6, 5, 8, 9, 0, time.UTC)))
+	assert.Equal(t, time.Local, info.DeletionDate.Location())
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"empty":          "",
+		"missing header": "Path=/file.txt\nDeletionDate=2024-05-06T07:08:09\n",
+		"wrong header":   "[Desktop Entry]\nPath=/file.txt\nDeletionDate=2024-05-06T07:08:09\n",
+		"missing path":   "[Trash Info]\nDeletionDate=2024-05-06T07:08:09\n",
+		"empty path":     "[Trash Info]\nPath=\nDeletionDate=2024-05-06T07:08:09\n",
+		"missing date":   "[Trash Info]\nPath=/file.txt\n",
+		"invalid date":   "[Trash Info]\nPath=/file.txt\nDeletionDate=yesterday\n",
+		"invalid escape": "[Trash Info]\nPath=/file%ZZ.txt\nDeletionDate=2024-05-06T07:08:09\n",
+		"invalid line":   "[Trash Info]\nPath=/file.txt\nDeletionDate=2024-05-06T07:08:09\ngarbage\n",
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := trashinfo.Parse(strings.NewReader(input))
+			require.Error(t, err)
+		})
+	}
+}
+
+func Test_WriteTo(t *testing.T) {
+	info := trashinfo.Info{
+		Path:         "/home/user/my file%.txt",
+		DeletionDate: time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local),
+		Extra:        map[string]string{"B": "2", "A": "1"},
+	}
+
+	var buffer bytes.Buffer
+	written, err := info.WriteTo(&buffer)
+	require.NoError(t, err)
+	require.Equal(t, int64(buffer.Len()), written)
+	require.Equal(t, "[Trash Info]\nPath=/home/user/my%20file%25.txt\nDeletionDate=2024-05-06T07:08:09\nA=1\nB=2\n", buffer.String())
+
+	parsed, err := trashinfo.Parse(&buffer)
+	require.NoError(t, err)
+	require.Equal(t, info, parsed)
+}
@@ -2,7 +2,11 @@ package wastebasket
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
 	"time"
+
+	"github.com/Bios-Marcel/wastebasket/v2/internal"
 )
 
 // TrashedFileInfo represents a file that has been deleted and now resides
@@ -12,30 +16,356 @@ type TrashedFileInfo interface {
 	OriginalPath() string
 	// DeletionDate is the deletion date in the computers local timezone.
 	DeletionDate() time.Time
-	// Restore will attempt restoring the file to its previous location.
+	// Restore will attempt restoring the file to its previous location. This
+	// is the same as calling RestoreTo with the original path.
 	Restore(force bool) error
+	// RestoreTo will attempt restoring the file to the given destination,
+	// instead of its previous location. The destination is the new path of
+	// the file, not the directory it will be placed in.
+	RestoreTo(dest string, options RestoreOptions) error
+	// RestorePath restores a single file out of a trashed directory to the
+	// given destination. The path is relative to the trashed directory. The
+	// directory itself stays in the trash. Missing parent directories of the
+	// destination are created. If the destination exists, ErrAlreadyExists
+	// is returned.
+	RestorePath(relative, dest string) error
 	// Delete will permanently deleting the underlying file. Note that we do not
 	// zero the respective bytes on the disk.
 	Delete() error
-	// UniqueIdentifier can be used to uniquely identify a file if the path and
-	// deletion date are exactly the same. This CAN be useful file restoration.
+	// UniqueIdentifier uniquely identifies the file, even if the path and
+	// deletion date are exactly the same. It stays the same as long as the
+	// file is in the trash and can be passed to Lookup and RestoreByID.
 	UniqueIdentifier() string
+	// Stat returns information about the file inside of the trashbin. Note
+	// that the name is the name inside of the trashbin, which doesn't
+	// necessarily match the original name. Symlinks aren't followed.
+	Stat() (fs.FileInfo, error)
+	// Size is the size of the file in bytes. What is measured depends on the
+	// platform and the file type:
+	//
+	//   - Unix files and symlinks: The apparent size, as reported by stat.
+	//   - Unix directories: The disk space used by the directory and its
+	//     content, counting the allocated blocks just like `du -B1`. This is
+	//     what the trash specification demands for the directorysizes cache,
+	//     which is used if it is up to date.
+	//   - Windows: The apparent size stored in the $I file. For directories,
+	//     this is the sum of the contained files.
+	//
+	// Therefore, on Unix, a directory containing a single small file is
+	// measured as at least a few kilobytes. The size is calculated on each
+	// call, as this can be expensive for big directories.
+	Size() (int64, error)
+	// Open opens the file inside of the trashbin for reading, without
+	// restoring it.
+	Open() (fs.File, error)
+	// FS allows browsing the content of a trashed directory, without
+	// restoring it. If the trashed file isn't a directory, all operations
+	// on the returned FS will fail.
+	FS() fs.FS
 }
 
 type QueryResult struct {
 	// Matches are the query results, mapped from the query input (globa / path)
-	// to the respective trashed files. Note that the same file can be trashed
+	// to the respective trashed files. When querying all files, the key is
+	// the trash directory instead. Note that the same file can be trashed
 	// multiple times and a glob can match multiple files. Therefore, expect
 	// multiple entries in both scenarios.
 	Matches map[string][]TrashedFileInfo
+	// Errors contains an *EntryError for each trash entry that couldn't be
+	// read, for example because its metadata is malformed. These entries are
+	// skipped, instead of failing the whole query.
+	Errors []error
+}
+
+// EntryError describes a single entry of the trash that couldn't be read.
+type EntryError struct {
+	// InfoPath is the path of the file containing the metadata of the entry.
+	InfoPath string
+	Err      error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("error reading trash entry '%s': %s", e.InfoPath, e.Err)
 }
 
-// QueryOptions allows to configure the Query-Call. Options Globs and Paths
-// aren't allowed to both be set.
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// QueryOptions allows to configure the Query-Call.
 type QueryOptions struct {
+	// Glob is the same as setting Match to MatchGlob.
+	//
+	// Deprecated: Use Match instead.
 	Glob bool
-	// Search can be relative or absolute.
+	// Search contains the patterns to search for. How they are matched
+	// against the original paths of the trashed files, depends on Match.
 	Search []string
+	// Match defines how Search is matched, by default the paths have to
+	// match exactly.
+	Match MatchMode
+	// CaseInsensitive ignores casing when matching, this applies to all
+	// match modes.
+	CaseInsensitive bool
+	// All matches every trashed file, Search and Glob mustn't be set. The
+	// matches are grouped by the trash directory they reside in, instead of
+	// the search input.
+	All bool
+
+	// The following filters are applied in addition to the search. Zero
+	// values disable the respective filter.
+
+	// DeletedAfter excludes all files deleted before the given time.
+	DeletedAfter time.Time
+	// DeletedBefore excludes all files deleted at or after the given time.
+	DeletedBefore time.Time
+	// MinSize excludes all files smaller than the given amount of bytes.
+	// Files are measured as described by TrashedFileInfo.Size.
+	MinSize int64
+	// MaxSize excludes all files bigger than the given amount of bytes.
+	// Files are measured as described by TrashedFileInfo.Size.
+	MaxSize int64
+	// Type excludes all files not of the given type.
+	Type FileType
+	// Under excludes all files whose original path isn't inside of the given
+	// directory. The path can be relative or absolute.
+	Under string
+}
+
+// MatchMode defines how the search patterns of a query are matched against
+// the original paths of the trashed files. Matches are always keyed by the
+// pattern that matched.
+type MatchMode int
+
+const (
+	// MatchExact requires the original path to be equal to the searched
+	// path. The searched path can be relative or absolute.
+	MatchExact MatchMode = iota
+	// MatchGlob matches the original path against a glob. Note that
+	// wildcards also match path separators.
+	MatchGlob
+	// MatchRegexp matches the original path against a regular expression.
+	// The expression isn't anchored, use ^ and $ to match the whole path.
+	MatchRegexp
+	// MatchSubstring matches if the original path contains the pattern.
+	MatchSubstring
+	// MatchBasename requires the last element of the original path to be
+	// equal to the pattern.
+	MatchBasename
+)
+
+// FileType is the type of a trashed file, used for filtering queries.
+type FileType int
+
+const (
+	// FileTypeAny matches files of any type.
+	FileTypeAny FileType = iota
+	// FileTypeRegular matches regular files only.
+	FileTypeRegular
+	// FileTypeDirectory matches directories only.
+	FileTypeDirectory
+	// FileTypeSymlink matches symlinks only. Symlinks are never followed.
+	FileTypeSymlink
+)
+
+// RestoreOptions allows to configure the RestoreTo-Call.
+type RestoreOptions = internal.RestoreOptions
+
+// ConflictPolicy defines what happens if the destination of a restore
+// already exists.
+type ConflictPolicy = internal.ConflictPolicy
+
+const (
+	// ConflictFail aborts the restore with ErrAlreadyExists.
+	ConflictFail = internal.ConflictFail
+	// ConflictOverwrite removes the existing file before restoring.
+	ConflictOverwrite = internal.ConflictOverwrite
+	// ConflictSkip leaves the file in the trash and returns ErrSkipped.
+	ConflictSkip = internal.ConflictSkip
+	// ConflictKeepBoth restores the file next to the existing one, using
+	// the name `name (restored N).ext`, where N is the first free number.
+	ConflictKeepBoth = internal.ConflictKeepBoth
+	// ConflictOverwriteIfOlder overwrites the existing file if its
+	// modification time is older than the one of the trashed file. Otherwise,
+	// the file is left in the trash and ErrSkipped is returned.
+	ConflictOverwriteIfOlder = internal.ConflictOverwriteIfOlder
+	// ConflictMerge restores a trashed directory into an existing directory,
+	// entry by entry. Nested directories are merged as well. Conflicting
+	// files are left in the trash and ErrAlreadyExists is returned. If
+	// either of both files isn't a directory, this behaves like
+	// ConflictFail.
+	ConflictMerge = internal.ConflictMerge
+)
+
+// RestoreError is returned if a trashed file couldn't be restored.
+type RestoreError struct {
+	// Path is the path of the file inside of the trash.
+	Path string
+	// Dest is the path the file was supposed to be restored to.
+	Dest string
+	Err  error
+}
+
+func (e *RestoreError) Error() string {
+	return fmt.Sprintf("error restoring '%s' to '%s': %s", e.Path, e.Dest, e.Err)
+}
+
+func (e *RestoreError) Unwrap() error {
+	return e.Err
+}
+
+// EmptyOptions allows to configure the EmptyWithOptions-Call. The scope
+// options select the trash directories, the remaining options select the
+// files inside of them. If an option is zero, it doesn't restrict anything.
+type EmptyOptions struct {
+	// HomeOnly only empties the home trash. On Windows, this is the
+	// recycle bin on the volume of the user's home directory.
+	HomeOnly bool
+	// Mount only empties the trash directories of the given mount point or
+	// topdir, including the home trash, if it is located on that mount. On
+	// Windows, this is the recycle bin of the given volume, such as `D:\`. Can't be combined with HomeOnly. If the path isn't a mount
+	// point, ErrUnknownMount is returned.
+	Mount string
+
+	// OlderThan only deletes files that have been trashed longer ago than
+	// the given duration.
+	OlderThan time.Duration
+	// Match only deletes files whose original path matches the given glob.
+	Match string
+	// Under only deletes files whose original path is inside of the given
+	// directory. The path can be relative or absolute.
+	Under string
+}
+
+// PruneOptions allows to configure the Prune-Call.
+type PruneOptions struct {
+	// MaxTotalBytes is the maximum size of each trash directory, which is
+	// the sum of the sizes of its files, as described by
+	// TrashedFileInfo.Size. Must be positive.
+	MaxTotalBytes int64
+	// DryRun only reports which files would be deleted, without deleting
+	// them.
+	DryRun bool
+}
+
+// PruneReport is the result of a Prune-Call.
+type PruneReport struct {
+	// Results contains a result for each trash directory containing files.
+	Results []PruneResult
+	// FreedBytes is the sum of all FreedBytes of the Results.
+	FreedBytes int64
+}
+
+// PruneResult describes the pruning of a single trash directory.
+type PruneResult struct {
+	// TrashDir is the trash directory, which was pruned.
+	TrashDir string
+	// TotalBytes is the sum of the sizes of all files before pruning, see
+	// TrashedFileInfo.Size.
+	TotalBytes int64
+	// Deleted contains the deleted files, oldest first. In a dry run, these
+	// are the files that would have been deleted.
+	Deleted []TrashedFileInfo
+	// FreedBytes is the size of all deleted files.
+	FreedBytes int64
+}
+
+// EmptyReport describes the outcome of an EmptyWithReport-Call.
+type EmptyReport struct {
+	// Results contains one result per trash directory that exists.
+	Results []EmptyResult
+	// Removed is the number of removed entries of all trash directories.
+	Removed int
+	// FreedBytes is the size of the removed entries of all trash
+	// directories.
+	FreedBytes int64
+}
+
+// EmptyResult describes the outcome of emptying a single trash directory.
+type EmptyResult struct {
+	// TrashDir is the trash directory, which was emptied.
+	TrashDir string
+	// Removed is the number of removed entries.
+	Removed int
+	// FreedBytes is the sum of the sizes of the removed entries, see
+	// TrashedFileInfo.Size. Entries whose size can't be determined are still
+	// removed, but aren't accounted for.
+	FreedBytes int64
+	// Failures contains the entries that couldn't be removed. If the trash
+	// directory can't be read at all, the directory itself is contained.
+	Failures []EmptyFailure
+}
+
+// EmptyFailure describes a path that couldn't be removed.
+type EmptyFailure struct {
+	// Path is the original path of the entry or the path of the directory
+	// that couldn't be read.
+	Path   string
+	Reason FailureReason
+	Err    error
+}
+
+// FailureReason categorizes why a path couldn't be removed.
+type FailureReason int
+
+const (
+	// FailureError is any failure not covered by a more specific reason.
+	FailureError FailureReason = iota
+	// FailurePermissionDenied indicates that the path was skipped, as we
+	// aren't allowed to remove it.
+	FailurePermissionDenied
+	// FailureReadOnly indicates that the path was skipped, as it is located
+	// on a read-only filesystem.
+	FailureReadOnly
+)
+
+func (reason FailureReason) String() string {
+	switch reason {
+	case FailurePermissionDenied:
+		return "skipped: permission denied"
+	case FailureReadOnly:
+		return "skipped: read-only filesystem"
+	default:
+		return "error"
+	}
+}
+
+// TrashOptions allows to configure the TrashWithOptions-Call.
+type TrashOptions struct {
+	// ContinueOnError makes sure that all paths are attempted to be trashed,
+	// even if some of them fail. By default, the first failing path aborts
+	// the call, leaving previous paths trashed and never attempting the
+	// following paths.
+	ContinueOnError bool
+}
+
+// TrashReport is the result of a TrashWithOptions-Call.
+type TrashReport struct {
+	// Results contains a result for each path that has been attempted to be
+	// trashed, in the order they were passed.
+	Results []TrashResult
+}
+
+// TrashResult describes what happened to a single path passed to
+// TrashWithOptions. If a path didn't exist, the result will be empty,
+// except for the Path. Not all platforms provide all fields.
+type TrashResult struct {
+	// Path is the path as it was passed.
+	Path string
+	// TrashDir is the trash directory the file has been moved to.
+	TrashDir string
+	// TrashedName is the name of the file inside of the trash directory.
+	// This can differ from the original name, as the trash can't contain
+	// multiple files of the same name.
+	TrashedName string
+	// InfoPath is the path of the file containing the metadata needed to
+	// restore the file.
+	InfoPath string
+	// Info is the file inside of the trash, which can be used to restore or
+	// delete it again.
+	Info TrashedFileInfo
+	// Err is the reason the path couldn't be trashed.
+	Err error
 }
 
 var (
@@ -43,12 +373,71 @@ var (
 	// suport trashing files or the API isn't fully implemented.
 	ErrPlatformNotSupported = errors.New("platform not supported")
 	ErrAlreadyExists        = errors.New("couldn't restore file, already exists, apply force")
-	ErrOnlyOneGlobAllowed   = errors.New("only one glob is allowed")
+	// ErrSkipped indicates that a file has been left in the trash, as
+	// demanded by ConflictSkip or ConflictOverwriteIfOlder.
+	ErrSkipped = errors.New("restore skipped, file already exists")
+	// ErrOnlyOneGlobAllowed isn't returned anymore, as multiple globs are
+	// supported now.
+	//
+	// Deprecated: Multiple globs are allowed.
+	ErrOnlyOneGlobAllowed = errors.New("only one glob is allowed")
+	ErrSearchWithAll      = errors.New("search can't be combined with querying all files")
+	ErrInvalidMatchMode   = errors.New("invalid match mode")
+	// ErrInvalidID indicates that an identifier doesn't belong to any trash
+	// directory of the current user.
+	ErrInvalidID = errors.New("invalid identifier")
+	// ErrNotFound indicates that a trashed file doesn't exist (anymore).
+	ErrNotFound = errors.New("trashed file not found")
+	// ErrConflictingScopes indicates that EmptyOptions.HomeOnly and
+	// EmptyOptions.Mount have been combined.
+	ErrConflictingScopes = errors.New("home only can't be combined with a mount")
+	// ErrUnknownMount indicates that EmptyOptions.Mount isn't a mount point.
+	// Otherwise, a typo would silently empty nothing.
+	ErrUnknownMount = errors.New("unknown mount point")
+	// ErrNegativeDuration indicates that EmptyOptions.OlderThan is negative.
+	ErrNegativeDuration = errors.New("duration mustn't be negative")
 )
 
+func (options EmptyOptions) validate() error {
+	if options.HomeOnly && options.Mount != "" {
+		return ErrConflictingScopes
+	}
+	if options.OlderThan < 0 {
+		return ErrNegativeDuration
+	}
+	return nil
+}
+
+// selectsFiles indicates whether only some of the files in the selected
+// trash directories are to be deleted.
+func (options EmptyOptions) selectsFiles() bool {
+	return options.OlderThan > 0 || options.Match != "" || options.Under != ""
+}
+
 func (options QueryOptions) validate() error {
-	if options.Glob && len(options.Search) > 1 {
-		return ErrOnlyOneGlobAllowed
+	if options.All && (options.Glob || len(options.Search) > 0) {
+		return ErrSearchWithAll
+	}
+	if options.Match < MatchExact || options.Match > MatchBasename {
+		return ErrInvalidMatchMode
+	}
+	if options.Glob && options.Match != MatchExact && options.Match != MatchGlob {
+		return fmt.Errorf("%w: glob can't be combined with other match modes", ErrInvalidMatchMode)
 	}
 	return nil
 }
+
+// matchMode returns the effective match mode, respecting the deprecated Glob
+// option.
+func (options QueryOptions) matchMode() MatchMode {
+	if options.Glob {
+		return MatchGlob
+	}
+	return options.Match
+}
+
+// List returns all trashed files, grouped by the trash directory they reside
+// in. This is the same as calling Query with QueryOptions.All.
+func List() (*QueryResult, error) {
+	return Query(QueryOptions{All: true})
+}
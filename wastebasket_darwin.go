@@ -1,7 +1,10 @@
 package wastebasket
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"iter"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -37,12 +40,79 @@ func Trash(paths ...string) error {
 	return nil
 }
 
+// TrashWithOptions is the same as Trash, but allows configuring the error
+// handling and reports the result for each path. The Finder doesn't tell us
+// where it puts the files, so only the Path and Err are reported.
+func TrashWithOptions(options TrashOptions, paths ...string) (*TrashReport, error) {
+	report := &TrashReport{Results: make([]TrashResult, 0, len(paths))}
+	var errs []error
+	for _, path := range paths {
+		result := TrashResult{Path: path, Err: Trash(path)}
+		report.Results = append(report.Results, result)
+
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("error trashing '%s': %w", path, result.Err))
+			if !options.ContinueOnError {
+				break
+			}
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// TrashAndReturn is not supported, as the Finder doesn't tell us where it
+// puts the files and querying isn't supported either.
+func TrashAndReturn(paths ...string) ([]TrashedFileInfo, error) {
+	return nil, ErrPlatformNotSupported
+}
+
 // Empty clears the platforms trashbin. It uses the `Finder` app to empty the trashbin.
 func Empty() error {
 	return exec.Command("osascript", "-e", `tell app "Finder" to empty`).Run()
 }
 
+// EmptyWithOptions only supports emptying the whole trashbin, as querying
+// isn't supported and the Finder doesn't allow choosing a trashbin.
+func EmptyWithOptions(options EmptyOptions) error {
+	if err := options.validate(); err != nil {
+		return fmt.Errorf("error validating options: %w", err)
+	}
+	if options.HomeOnly || options.Mount != "" || options.selectsFiles() {
+		return ErrPlatformNotSupported
+	}
+	return Empty()
+}
+
+// EmptyWithReport is not supported, as the Finder doesn't tell us what it
+// deleted.
+func EmptyWithReport(options EmptyOptions) (*EmptyReport, error) {
+	return nil, ErrPlatformNotSupported
+}
+
+// Prune is not supported.
+func Prune(options PruneOptions) (*PruneReport, error) {
+	return nil, ErrPlatformNotSupported
+}
+
 // Query is not supported.
 func Query(options QueryOptions) (*QueryResult, error) {
 	return nil, ErrPlatformNotSupported
 }
+
+// Lookup is not supported.
+func Lookup(id string) (TrashedFileInfo, error) {
+	return nil, ErrPlatformNotSupported
+}
+
+// RestoreByID is not supported.
+func RestoreByID(id string, options RestoreOptions) error {
+	return ErrPlatformNotSupported
+}
+
+// QueryIter is not supported.
+func QueryIter(ctx context.Context, options QueryOptions) iter.Seq2[TrashedFileInfo, error] {
+	return func(yield func(TrashedFileInfo, error) bool) {
+		yield(nil, ErrPlatformNotSupported)
+	}
+}
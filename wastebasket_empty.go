@@ -0,0 +1,154 @@
+//go:build freebsd || openbsd || netbsd || linux || windows
+
+package wastebasket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// EmptyWithOptions is the same as Empty, but allows to only empty some of the
+// trash directories or to only delete some of the trashed files. Files that
+// can't be deleted, don't stop the others from being deleted, instead, all
+// errors are returned at once.
+func EmptyWithOptions(options EmptyOptions) error {
+	if err := options.validate(); err != nil {
+		return fmt.Errorf("error validating options: %w", err)
+	}
+
+	inScope, err := emptyScope(options)
+	if err != nil {
+		return err
+	}
+
+	if !options.selectsFiles() {
+		if inScope == nil {
+			return Empty()
+		}
+		return emptyScoped(inScope)
+	}
+
+	report, err := deleteMatches(options, inScope, false)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, result := range report.Results {
+		for _, failure := range result.Failures {
+			errs = append(errs, fmt.Errorf("error deleting '%s': %w", failure.Path, failure.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EmptyWithReport is the same as EmptyWithOptions, but reports what has been
+// removed from each trash directory. Entries that couldn't be removed are
+// part of the report, instead of being returned as an error. Unlike Empty,
+// entries we aren't allowed to remove aren't silently skipped. An error is
+// only returned, if the trash directories couldn't be determined.
+func EmptyWithReport(options EmptyOptions) (*EmptyReport, error) {
+	if err := options.validate(); err != nil {
+		return nil, fmt.Errorf("error validating options: %w", err)
+	}
+
+	inScope, err := emptyScope(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if !options.selectsFiles() {
+		return emptyWithReport(inScope)
+	}
+	return deleteMatches(options, inScope, true)
+}
+
+// deleteMatches deletes all files selected by the options one by one. The
+// freed bytes are only calculated if measure is set.
+func deleteMatches(options EmptyOptions, inScope func(trashDir string) bool, measure bool) (*EmptyReport, error) {
+	queryOptions := QueryOptions{
+		All:   true,
+		Under: options.Under,
+	}
+	if options.OlderThan > 0 {
+		queryOptions.DeletedBefore = time.Now().Add(-options.OlderThan)
+	}
+	if options.Match != "" {
+		queryOptions.All = false
+		queryOptions.Search = []string{options.Match}
+		queryOptions.Match = MatchGlob
+	}
+
+	report := &EmptyReport{}
+	resultIndices := make(map[string]int)
+	sizers := make(map[string]func(TrashedFileInfo) (int64, error))
+	for match, err := range query(context.Background(), queryOptions) {
+		if err != nil {
+			// Without readable metadata, we don't know whether the entry
+			// should be deleted, so it is kept.
+			var entryErr *EntryError
+			if errors.As(err, &entryErr) {
+				continue
+			}
+			return nil, err
+		}
+
+		if inScope != nil && !inScope(match.trashDir) {
+			continue
+		}
+
+		index, ok := resultIndices[match.trashDir]
+		if !ok {
+			index = len(report.Results)
+			resultIndices[match.trashDir] = index
+			report.Results = append(report.Results, EmptyResult{TrashDir: match.trashDir})
+		}
+		result := &report.Results[index]
+
+		var size int64
+		if measure {
+			sizer, ok := sizers[match.trashDir]
+			if !ok {
+				sizer = trashDirSizer(match.trashDir)
+				sizers[match.trashDir] = sizer
+			}
+			size, _ = sizer(match.info)
+		}
+		if err := match.info.Delete(); err != nil {
+			result.Failures = append(result.Failures, EmptyFailure{
+				Path:   match.info.OriginalPath(),
+				Reason: failureReason(err),
+				Err:    err,
+			})
+			continue
+		}
+		result.Removed++
+		result.FreedBytes += size
+	}
+
+	report.sum()
+	return report, nil
+}
+
+// sum calculates the totals of all results.
+func (report *EmptyReport) sum() {
+	report.Removed, report.FreedBytes = 0, 0
+	for _, result := range report.Results {
+		report.Removed += result.Removed
+		report.FreedBytes += result.FreedBytes
+	}
+}
+
+func failureReason(err error) FailureReason {
+	switch {
+	case isReadOnly(err):
+		return FailureReadOnly
+	case errors.Is(err, fs.ErrPermission):
+		return FailurePermissionDenied
+	default:
+		return FailureError
+	}
+}
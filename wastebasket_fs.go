@@ -0,0 +1,357 @@
+package wastebasket
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FS returns a read-only view of all trashed files, in which each file is
+// located at its original path, for example `home/user/docs/report.pdf`. On
+// Windows, the first element is the volume, for example
+// `C/Users/user/report.pdf`. Trashed directories can be browsed as well.
+//
+// If the same path has been trashed multiple times, or a trashed file is
+// located at a path that is also a parent directory of other trashed files,
+// the files are suffixed with their unique identifier, for example
+// `report.pdf@<id>`.
+//
+// The view is a snapshot, files trashed afterwards aren't part of it. Entries
+// that can't be read are omitted. The returned FS implements fs.ReadDirFS,
+// fs.StatFS and fs.ReadFileFS.
+func FS() (fs.FS, error) {
+	result, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []TrashedFileInfo
+	for _, matches := range result.Matches {
+		entries = append(entries, matches...)
+	}
+	return newTrashFS(entries), nil
+}
+
+var errIsDirectory = errors.New("is a directory")
+
+// trashFS is a tree of directories, mirroring the original paths of the
+// trashed files.
+type trashFS struct {
+	root *fsNode
+}
+
+// fsNode is either a directory that only exists in order to contain trashed
+// files, or a trashed file itself.
+type fsNode struct {
+	name     string
+	entry    TrashedFileInfo
+	children map[string]*fsNode
+}
+
+func newDirNode(name string) *fsNode {
+	return &fsNode{name: name, children: make(map[string]*fsNode)}
+}
+
+func newTrashFS(entries []TrashedFileInfo) *trashFS {
+	root := newDirNode(".")
+
+	byPath := make(map[string][]TrashedFileInfo)
+	for _, entry := range entries {
+		name := virtualPath(entry.OriginalPath())
+		if name == "" || name == "." {
+			continue
+		}
+		byPath[name] = append(byPath[name], entry)
+	}
+
+	// All parent directories have to be known beforehand, so we can detect
+	// trashed files conflicting with them.
+	for name := range byPath {
+		node := root
+		for _, element := range strings.Split(path.Dir(name), "/") {
+			if element == "." {
+				break
+			}
+			child, ok := node.children[element]
+			if !ok {
+				child = newDirNode(element)
+				node.children[element] = child
+			}
+			node = child
+		}
+	}
+
+	for name, entries := range byPath {
+		parent, _, _ := root.resolve(path.Dir(name))
+		base := path.Base(name)
+		if _, conflict := parent.children[base]; !conflict && len(entries) == 1 {
+			parent.children[base] = &fsNode{name: base, entry: entries[0]}
+			continue
+		}
+
+		for _, entry := range entries {
+			versioned := base + "@" + entry.UniqueIdentifier()
+			parent.children[versioned] = &fsNode{name: versioned, entry: entry}
+		}
+	}
+
+	return &trashFS{root: root}
+}
+
+// virtualPath converts an absolute path into a valid fs.FS path. The volume
+// name is kept as the first element, without colon.
+func virtualPath(originalPath string) string {
+	volume := filepath.VolumeName(originalPath)
+	rest := filepath.ToSlash(originalPath[len(volume):])
+	volume = strings.Trim(strings.ReplaceAll(filepath.ToSlash(volume), ":", ""), "/")
+	return strings.TrimPrefix(path.Join(volume, rest), "/")
+}
+
+// resolve looks up the node at the given path. If the path points into a
+// trashed directory, the trashed directory is returned, alongside the path
+// relative to it.
+func (node *fsNode) resolve(name string) (*fsNode, string, bool) {
+	if name == "." {
+		return node, "", true
+	}
+
+	elements := strings.Split(name, "/")
+	for index, element := range elements {
+		if node.entry != nil {
+			return node, strings.Join(elements[index:], "/"), true
+		}
+
+		child, ok := node.children[element]
+		if !ok {
+			return nil, "", false
+		}
+		node = child
+	}
+
+	return node, "", true
+}
+
+func (t *trashFS) lookup(op, name string) (*fsNode, string, error) {
+	if !fs.ValidPath(name) {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	node, rest, ok := t.root.resolve(name)
+	if !ok {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return node, rest, nil
+}
+
+// renamePathError replaces the path inside of the trash with the path inside
+// of the FS.
+func renamePathError(err error, name string) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return &fs.PathError{Op: pathErr.Op, Path: name, Err: pathErr.Err}
+	}
+	return err
+}
+
+func (t *trashFS) Open(name string) (fs.File, error) {
+	node, rest, err := t.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.entry == nil {
+		entries, err := node.dirEntries()
+		if err != nil {
+			return nil, renamePathError(err, name)
+		}
+		return &dirFile{name: name, node: node, entries: entries}, nil
+	}
+
+	if rest != "" {
+		file, err := node.entry.FS().Open(rest)
+		return file, renamePathError(err, name)
+	}
+
+	file, err := node.entry.Open()
+	if err != nil {
+		return nil, renamePathError(err, name)
+	}
+	return &renamedFile{File: file, name: node.name}, nil
+}
+
+func (t *trashFS) Stat(name string) (fs.FileInfo, error) {
+	node, rest, err := t.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.entry == nil {
+		return dirInfo{name: node.name}, nil
+	}
+
+	if rest != "" {
+		info, err := fs.Stat(node.entry.FS(), rest)
+		return info, renamePathError(err, name)
+	}
+
+	info, err := node.entry.Stat()
+	if err != nil {
+		return nil, renamePathError(err, name)
+	}
+	return renamedFileInfo{FileInfo: info, name: node.name}, nil
+}
+
+func (t *trashFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	node, rest, err := t.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.entry == nil {
+		entries, err := node.dirEntries()
+		return entries, renamePathError(err, name)
+	}
+
+	if rest == "" {
+		rest = "."
+	}
+	entries, err := fs.ReadDir(node.entry.FS(), rest)
+	return entries, renamePathError(err, name)
+}
+
+func (t *trashFS) ReadFile(name string) ([]byte, error) {
+	node, rest, err := t.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.entry == nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDirectory}
+	}
+
+	if rest != "" {
+		content, err := fs.ReadFile(node.entry.FS(), rest)
+		return content, renamePathError(err, name)
+	}
+
+	file, err := node.entry.Open()
+	if err != nil {
+		return nil, renamePathError(err, name)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	return content, renamePathError(err, name)
+}
+
+// dirEntries returns the sorted entries of a directory node. Trashed files
+// that have been removed in the meantime are skipped.
+func (node *fsNode) dirEntries() ([]fs.DirEntry, error) {
+	entries := make([]fs.DirEntry, 0, len(node.children))
+	for _, child := range node.children {
+		if child.entry == nil {
+			entries = append(entries, fs.FileInfoToDirEntry(dirInfo{name: child.name}))
+			continue
+		}
+
+		info, err := child.entry.Stat()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(renamedFileInfo{FileInfo: info, name: child.name}))
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// dirFile is an opened directory that only exists in order to contain
+// trashed files.
+type dirFile struct {
+	name    string
+	node    *fsNode
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) {
+	return dirInfo{name: d.node.name}, nil
+}
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errIsDirectory}
+}
+
+func (d *dirFile) Close() error {
+	return nil
+}
+
+func (d *dirFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if count <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	count = min(count, len(remaining))
+	d.offset += count
+	return remaining[:count], nil
+}
+
+// dirInfo describes a directory that only exists in order to contain
+// trashed files.
+type dirInfo struct {
+	name string
+}
+
+func (d dirInfo) Name() string       { return d.name }
+func (d dirInfo) Size() int64        { return 0 }
+func (d dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (d dirInfo) ModTime() time.Time { return time.Time{} }
+func (d dirInfo) IsDir() bool        { return true }
+func (d dirInfo) Sys() any           { return nil }
+
+// renamedFile is a trashed file, which is presented under its original name,
+// instead of its name inside of the trash.
+type renamedFile struct {
+	fs.File
+	name string
+}
+
+func (f *renamedFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return renamedFileInfo{FileInfo: info, name: f.name}, nil
+}
+
+func (f *renamedFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	dir, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("not a directory")}
+	}
+	return dir.ReadDir(count)
+}
+
+type renamedFileInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i renamedFileInfo) Name() string {
+	return i.name
+}
@@ -3,24 +3,27 @@
 package wastebasket
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
+	"iter"
 	"os"
 	"os/user"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/Bios-Marcel/wastebasket/v2/internal"
+	"github.com/Bios-Marcel/wastebasket/v2/trashinfo"
 	"github.com/Bios-Marcel/wastebasket/v2/wastebasket_nix"
-	"github.com/gobwas/glob"
 )
 
 // RFC3339 is the same as time.RFC3339 but without timezones.
-const RFC3339 string = "2006-01-02T15:04:05"
+const RFC3339 string = trashinfo.DateLayout
 
 // cachedInformation makes sure we don't constantly check for the
 // directory and which drive it is on again.
@@ -89,288 +92,714 @@ func topdir(potentialTopdirs []string, path string) (string, error) {
 	return matchingDir, nil
 }
 
+// Trash moves the given files or directories including their content into
+// the trash. Non-existent paths are ignored. The call is aborted on the
+// first failing path, leaving previous paths trashed. Use TrashWithOptions
+// to change this behaviour.
 func Trash(paths ...string) error {
-	// RFC3339 defined in the time package contains the timezone offset, which
-	// isn't defined by the spec and causes issues in some trash tools, such
-	// as trash-cli.
-	deletionDate := time.Now().Format(RFC3339)
+	_, err := TrashWithOptions(TrashOptions{}, paths...)
+	return err
+}
+
+// TrashWithOptions is the same as Trash, but allows configuring the error
+// handling and reports the result for each path.
+func TrashWithOptions(options TrashOptions, paths ...string) (*TrashReport, error) {
+	// The .trashinfo format doesn't store fractions of a second.
+	deletionDate := time.Now().Truncate(time.Second)
 	cache, err := getCache()
 	if err != nil {
-		return fmt.Errorf("error determining user trash directory: %w", err)
+		return nil, fmt.Errorf("error determining user trash directory: %w", err)
 	}
 
 	mounts, err := internal.Mounts()
 	if err != nil {
-		return fmt.Errorf("error retrieving mounts: %w", err)
+		return nil, fmt.Errorf("error retrieving mounts: %w", err)
 	}
 
-	for _, absPath := range paths {
-		var err error
-		absPath, err = filepath.Abs(absPath)
+	report := &TrashReport{Results: make([]TrashResult, 0, len(paths))}
+	var errs []error
+	for _, path := range paths {
+		result, err := trashPath(cache, mounts, deletionDate, path)
+		result.Path = path
+		result.Err = err
+		report.Results = append(report.Results, result)
+
 		if err != nil {
-			return fmt.Errorf("error retrieving absolute filepath: %w", err)
+			errs = append(errs, fmt.Errorf("error trashing '%s': %w", path, err))
+			if !options.ContinueOnError {
+				break
+			}
 		}
+	}
 
-		pathTopdir, err := topdir(mounts, absPath)
-		if err != nil {
-			return fmt.Errorf("error determining topdir: %w", err)
-		}
-
-		// We only support absolute filenames in the home trash. For
-		// topdirs, we use relative paths. This allows us to move a
-		// mount, while still keeping trash files recoverable.
-		var pathForTrashInfo string
-
-		var trashDir, filesDir, infoDir string
-		// Deleting accross partitions / mounts
-		if cache.topdir != pathTopdir {
-			// While getTopDir won't return an empty string with its current
-			// impl, this can change in the future, so beteter be safe than
-			// sorry.
-			if pathTopdir != "" {
-				var uid string
-				if currentUser, err := user.Current(); err != nil {
-					return fmt.Errorf("error getting current user: %w", err)
-				} else {
-					uid = currentUser.Uid
-				}
+	return report, errors.Join(errs...)
+}
 
-				trashDir = filepath.Join(pathTopdir, ".Trash")
+// TrashAndReturn is the same as Trash, but returns the entries created in
+// the trash. These can be used to restore or delete the files again,
+// without having to query the trash.
+func TrashAndReturn(paths ...string) ([]TrashedFileInfo, error) {
+	report, err := TrashWithOptions(TrashOptions{}, paths...)
+	if report == nil {
+		return nil, err
+	}
 
-				var useFallbackTopdirTrash bool
-				if trashDirStat, err := os.Stat(trashDir); err != nil {
-					if !os.IsNotExist(err) {
-						return fmt.Errorf("error checking for trash directory: %w", err)
-					}
-					useFallbackTopdirTrash = true
-				} else {
-					if trashDirStat.Mode()&fs.ModeSticky != 0 {
-						// If the topdir trash directory contains a trash for all
-						// users, it needs to have the sticky bit set. This is only
-						// required for .Trash though, not for .Trash-$uid.
-						useFallbackTopdirTrash = true
-					} else if trashDirStat.Mode()&os.ModeSymlink != 0 {
-						// Symlinks must not be used as per spec.
-						useFallbackTopdirTrash = true
-					}
-				}
+	infos := make([]TrashedFileInfo, 0, len(report.Results))
+	for _, result := range report.Results {
+		if result.Info != nil {
+			infos = append(infos, result.Info)
+		}
+	}
+	return infos, err
+}
 
-				pathForTrashInfo, err = filepath.Rel(pathTopdir, absPath)
-				if err != nil {
-					return fmt.Errorf("error retrieving relative path: %w", err)
-				}
+// trashPath trashes a single file. If the file doesn't exist, an empty
+// result is returned.
+func trashPath(cache *cache, mounts []string, deletionDate time.Time, path string) (TrashResult, error) {
+	var result TrashResult
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return result, fmt.Errorf("error retrieving absolute filepath: %w", err)
+	}
 
-				if !useFallbackTopdirTrash {
-					filesDir = filepath.Join(trashDir, uid, "files")
-					infoDir = filepath.Join(trashDir, uid, "info")
-				} else {
-					// If .Trash doesn't exist, we need to check for .Trash-$uid
-					// and create it if it doesn't exist. The spec however
-					// doesn't indicate that we should do the same with .Trash.
-					trashDir = filepath.Join(pathTopdir, ".Trash-"+uid)
-					filesDir = filepath.Join(trashDir, "files")
-					infoDir = filepath.Join(trashDir, "info")
-				}
+	pathTopdir, err := topdir(mounts, absPath)
+	if err != nil {
+		return result, fmt.Errorf("error determining topdir: %w", err)
+	}
 
+	// We only support absolute filenames in the home trash. For
+	// topdirs, we use relative paths. This allows us to move a
+	// mount, while still keeping trash files recoverable.
+	var pathForTrashInfo string
+
+	var trashDir, filesDir, infoDir string
+	// Deleting accross partitions / mounts
+	if cache.topdir != pathTopdir {
+		// While getTopDir won't return an empty string with its current
+		// impl, this can change in the future, so beteter be safe than
+		// sorry.
+		if pathTopdir != "" {
+			var uid string
+			if currentUser, err := user.Current(); err != nil {
+				return result, fmt.Errorf("error getting current user: %w", err)
+			} else {
+				uid = currentUser.Uid
 			}
-		}
 
-		if trashDir == "" {
-			// Fallback to home trash.
-			trashDir = cache.path
-			filesDir = filepath.Join(trashDir, "files")
-			infoDir = filepath.Join(trashDir, "info")
+			trashDir = filepath.Join(pathTopdir, ".Trash")
 
-			// Hometrash supports both relative and absolute paths.
-			if trashParent := filepath.Dir(trashDir); strings.HasPrefix(absPath, trashParent) {
-				relPath, err := filepath.Rel(trashParent, absPath)
-				if err != nil {
-					return fmt.Errorf("error retrieving relative path: %w", err)
+			var useFallbackTopdirTrash bool
+			if trashDirStat, err := os.Stat(trashDir); err != nil {
+				if !os.IsNotExist(err) {
+					return result, fmt.Errorf("error checking for trash directory: %w", err)
+				}
+				useFallbackTopdirTrash = true
+			} else {
+				if trashDirStat.Mode()&fs.ModeSticky != 0 {
+					// If the topdir trash directory contains a trash for all
+					// users, it needs to have the sticky bit set. This is only
+					// required for .Trash though, not for .Trash-$uid.
+					useFallbackTopdirTrash = true
+				} else if trashDirStat.Mode()&os.ModeSymlink != 0 {
+					// Symlinks must not be used as per spec.
+					useFallbackTopdirTrash = true
 				}
-				pathForTrashInfo = relPath
+			}
+
+			pathForTrashInfo, err = filepath.Rel(pathTopdir, absPath)
+			if err != nil {
+				return result, fmt.Errorf("error retrieving relative path: %w", err)
+			}
+
+			if !useFallbackTopdirTrash {
+				trashDir = filepath.Join(trashDir, uid)
+				filesDir = filepath.Join(trashDir, "files")
+				infoDir = filepath.Join(trashDir, "info")
 			} else {
-				pathForTrashInfo = absPath
+				// If .Trash doesn't exist, we need to check for .Trash-$uid
+				// and create it if it doesn't exist. The spec however
+				// doesn't indicate that we should do the same with .Trash.
+				trashDir = filepath.Join(pathTopdir, ".Trash-"+uid)
+				filesDir = filepath.Join(trashDir, "files")
+				infoDir = filepath.Join(trashDir, "info")
 			}
+
 		}
+	}
 
-		if err := os.MkdirAll(filesDir, 0o700); err != nil && !os.IsExist(err) {
-			return fmt.Errorf("error creating directory '%s': %w", filesDir, err)
+	if trashDir == "" {
+		// Fallback to home trash.
+		location, err := homeTrashLocation(cache, absPath)
+		if err != nil {
+			return result, err
 		}
-		if err := os.MkdirAll(infoDir, 0o700); err != nil && !os.IsExist(err) {
-			return fmt.Errorf("error creating directory '%s': %w", infoDir, err)
+		trashDir, filesDir, infoDir = location.trashDir, location.filesDir, location.infoDir
+		pathForTrashInfo = location.pathForTrashInfo
+	}
+
+	baseName := filepath.Base(absPath)
+	trashedFilePath, infoFileHandle, err := createTrashFiles(filesDir, infoDir, baseName)
+	if err != nil {
+		return result, err
+	}
+	// While we close manually later, we want to prevent a leak.
+	defer infoFileHandle.Close()
+
+	var copied bool
+	if err := os.Rename(absPath, trashedFilePath); err != nil {
+		// We save ourselvse the exists check at the start of the loop, as
+		// deleting non existing files probably does not happen that often.
+		if os.IsNotExist(err) {
+			// Since we already create the info file, we will have to manually delete it again.
+			name := infoFileHandle.Name()
+			infoFileHandle.Close()
+			// We ignore the error here, it isn't super important
+			os.Remove(name)
+			return result, nil
 		}
 
-		baseName := filepath.Base(absPath)
-		trashedFilePath := filepath.Join(filesDir, baseName)
-		trashedFileInfoPath := filepath.Join(infoDir, baseName) + ".trashinfo"
+		if !internal.IsCrossDevice(err) {
+			// All special treatment failed, return original os.Rename error
+			return result, fmt.Errorf("error moving file to trash: %w", err)
+		}
 
-		// We need to check whether the trash already contains a file with this
-		// name, since deleted files from different directories often have the
-		// same name. An example would be .gitignore files, they always have
-		// the same basename and therefore always the same trash path.
-		// We simply count up in this case. Since we've got the info file, we
-		// can map back to the original name later on.
+		// The topdir detection doesn't catch everything, for example bind
+		// mounts, btrfs subvolumes or overlay roots. In these cases, we
+		// fall back to copying the file into the home trash, as the spec
+		// allows for.
+		if trashDir != cache.path {
+			name := infoFileHandle.Name()
+			infoFileHandle.Close()
+			os.Remove(name)
 
-		var infoFileHandle *os.File
-		if exists, err := internal.FileExists(trashedFilePath); err != nil {
-			return err
-		} else if !exists {
-			// We save ourselves the FileExists check, as we can combine it
-			// with the opening of the file handle. This is a performance
-			// optimisation.
-			infoFileHandle, err = os.OpenFile(trashedFileInfoPath, os.O_EXCL|os.O_CREATE|os.O_WRONLY, 0o600)
+			location, err := homeTrashLocation(cache, absPath)
 			if err != nil {
-				if !os.IsExist(err) {
-					return fmt.Errorf("error creating info file: %w", err)
-				}
+				return result, err
+			}
+			trashDir = location.trashDir
+			pathForTrashInfo = location.pathForTrashInfo
+			trashedFilePath, infoFileHandle, err = createTrashFiles(location.filesDir, location.infoDir, baseName)
+			if err != nil {
+				return result, err
 			}
-
-			// While we close manually later, we want to prevent a leak.
 			defer infoFileHandle.Close()
 		}
 
-		// If there isn't a valid info file handle yet, it means that one
-		// of the two file names were already in use, requiring us to find
-		// two unique filenames eiter way.
-		if infoFileHandle == nil {
-			extension := filepath.Ext(baseName)
-			baseNameNoExtension := strings.TrimSuffix(baseName, extension)
-			for i := uint64(1); i != 0; i = i + 1 {
-				newBaseName := fmt.Sprintf("%s.%d%s", baseNameNoExtension, i, extension)
+		if err := copyToTrash(absPath, trashedFilePath, infoFileHandle); err != nil {
+			return result, err
+		}
+		copied = true
+	}
+
+	info := trashinfo.Info{Path: pathForTrashInfo, DeletionDate: deletionDate}
+	if _, err := info.WriteTo(infoFileHandle); err != nil {
+		return result, fmt.Errorf("error writing to info file: %w", err)
+	}
 
-				// The names of both files must always be the same, putting
-				// aside the .trashinfo extension.
-				trashedFilePath = filepath.Join(filesDir, newBaseName)
-				if exists, err := internal.FileExists(trashedFilePath); err != nil || exists {
-					continue
-				}
-				infoFileHandle, err = os.OpenFile(filepath.Join(infoDir, newBaseName+".trashinfo"), os.O_EXCL|os.O_CREATE|os.O_WRONLY, 0o600)
-				if err != nil {
-					if os.IsExist(err) {
-						continue
-					}
-					return fmt.Errorf("error creating info file: %w", err)
-				}
+	// The directorysizes file is merely a cache, so failing to update it
+	// doesn't make the trash operation fail.
+	_ = addDirectorySize(trashDir, trashedFilePath, infoFileHandle.Name())
+
+	result.TrashDir = trashDir
+	result.TrashedName = filepath.Base(trashedFilePath)
+	result.InfoPath = infoFileHandle.Name()
+	result.Info = newTrashedFileInfo(trashDir, result.InfoPath, trashedFilePath, absPath, deletionDate)
+
+	// The source is only removed once the copy has been verified and is
+	// restorable. If the removal fails midway, the source might be
+	// partially gone already, so we keep the copy either way.
+	if copied {
+		if err := os.RemoveAll(absPath); err != nil {
+			return result, fmt.Errorf("error removing file after copying it to trash: %w", err)
+		}
+	}
 
-				defer infoFileHandle.Close()
+	return result, nil
+}
 
-				// We found a valid name, where neither the file itself, nor
-				// the trashinfo file exist.
-				break
+// trashLocation describes where a file is put when being trashed and how
+// its original path is recorded.
+type trashLocation struct {
+	trashDir, filesDir, infoDir string
+	pathForTrashInfo            string
+}
+
+func homeTrashLocation(cache *cache, absPath string) (trashLocation, error) {
+	location := trashLocation{
+		trashDir: cache.path,
+		filesDir: filepath.Join(cache.path, "files"),
+		infoDir:  filepath.Join(cache.path, "info"),
+	}
+
+	// Hometrash supports both relative and absolute paths.
+	if trashParent := filepath.Dir(location.trashDir); strings.HasPrefix(absPath, trashParent) {
+		relPath, err := filepath.Rel(trashParent, absPath)
+		if err != nil {
+			return location, fmt.Errorf("error retrieving relative path: %w", err)
+		}
+		location.pathForTrashInfo = relPath
+	} else {
+		location.pathForTrashInfo = absPath
+	}
+
+	return location, nil
+}
+
+// createTrashFiles reserves a unique name in the given trash directories by
+// creating the info file. The returned handle has to be written to and
+// closed by the caller.
+func createTrashFiles(filesDir, infoDir, baseName string) (string, *os.File, error) {
+	if err := os.MkdirAll(filesDir, 0o700); err != nil && !os.IsExist(err) {
+		return "", nil, fmt.Errorf("error creating directory '%s': %w", filesDir, err)
+	}
+	if err := os.MkdirAll(infoDir, 0o700); err != nil && !os.IsExist(err) {
+		return "", nil, fmt.Errorf("error creating directory '%s': %w", infoDir, err)
+	}
+
+	trashedFilePath := filepath.Join(filesDir, baseName)
+	trashedFileInfoPath := filepath.Join(infoDir, baseName) + ".trashinfo"
+
+	// We need to check whether the trash already contains a file with this
+	// name, since deleted files from different directories often have the
+	// same name. An example would be .gitignore files, they always have
+	// the same basename and therefore always the same trash path.
+	// We simply count up in this case. Since we've got the info file, we
+	// can map back to the original name later on.
+
+	var infoFileHandle *os.File
+	if exists, err := internal.FileExists(trashedFilePath); err != nil {
+		return "", nil, err
+	} else if !exists {
+		// We save ourselves the FileExists check, as we can combine it
+		// with the opening of the file handle. This is a performance
+		// optimisation.
+		infoFileHandle, err = os.OpenFile(trashedFileInfoPath, os.O_EXCL|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			if !os.IsExist(err) {
+				return "", nil, fmt.Errorf("error creating info file: %w", err)
 			}
+			infoFileHandle = nil
 		}
+	}
 
-		if err := os.Rename(absPath, trashedFilePath); err != nil {
-			// We save ourselvse the exists check at the start of the loop, as
-			// deleting non existing files probably does not happen that often.
-			if os.IsNotExist(err) {
-				// Since we already create the info file, we will have to manually delete it again.
-				name := infoFileHandle.Name()
-				infoFileHandle.Close()
-				// We ignore the error here, it isn't super important
-				os.Remove(name)
+	// If there isn't a valid info file handle yet, it means that one
+	// of the two file names were already in use, requiring us to find
+	// two unique filenames eiter way.
+	if infoFileHandle == nil {
+		extension := filepath.Ext(baseName)
+		baseNameNoExtension := strings.TrimSuffix(baseName, extension)
+		for i := uint64(1); i != 0; i = i + 1 {
+			newBaseName := fmt.Sprintf("%s.%d%s", baseNameNoExtension, i, extension)
+
+			// The names of both files must always be the same, putting
+			// aside the .trashinfo extension.
+			trashedFilePath = filepath.Join(filesDir, newBaseName)
+			if exists, err := internal.FileExists(trashedFilePath); err != nil || exists {
 				continue
 			}
+			var err error
+			infoFileHandle, err = os.OpenFile(filepath.Join(infoDir, newBaseName+".trashinfo"), os.O_EXCL|os.O_CREATE|os.O_WRONLY, 0o600)
+			if err != nil {
+				if os.IsExist(err) {
+					continue
+				}
+				return "", nil, fmt.Errorf("error creating info file: %w", err)
+			}
 
-			// All special treatment failed, return original os.Rename error
-			return fmt.Errorf("error moving file to trash: %w", err)
+			// We found a valid name, where neither the file itself, nor
+			// the trashinfo file exist.
+			break
 		}
+	}
 
-		if _, err = infoFileHandle.WriteString(fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", internal.EscapeUrl(pathForTrashInfo), deletionDate)); err != nil {
-			return fmt.Errorf("error writing to info file: %w", err)
-		}
+	return trashedFilePath, infoFileHandle, nil
+}
+
+// copyToTrash is the fallback for when a file can't be moved into the trash,
+// since it resides on a different filesystem. On failure, both the copy and
+// the info file are cleaned up. Removing the source is up to the caller.
+func copyToTrash(absPath, trashedFilePath string, infoFileHandle *os.File) error {
+	if err := internal.CopyAll(absPath, trashedFilePath); err != nil {
+		// Partial copies are useless, so we clean up and leave the
+		// source untouched.
+		os.RemoveAll(trashedFilePath)
+		name := infoFileHandle.Name()
+		infoFileHandle.Close()
+		os.Remove(name)
+		return fmt.Errorf("error copying file to trash: %w", err)
+	}
+
+	if err := internal.VerifyCopy(absPath, trashedFilePath); err != nil {
+		os.RemoveAll(trashedFilePath)
+		name := infoFileHandle.Name()
+		infoFileHandle.Close()
+		os.Remove(name)
+		return fmt.Errorf("error verifying copy in trash: %w", err)
 	}
 
 	return nil
 }
 
-func isPermissionDenied(err error) bool {
-	if err == os.ErrPermission {
-		return true
+// addDirectorySize adds a freshly trashed directory to the directorysizes
+// cache of its trash directory. Files are ignored, as their size can simply
+// be retrieved via stat.
+func addDirectorySize(trashDir, trashedFilePath, infoPath string) error {
+	info, err := os.Lstat(trashedFilePath)
+	if err != nil {
+		return fmt.Errorf("error retrieving file info: %w", err)
+	}
+	if !info.IsDir() {
+		return nil
 	}
 
-	if pe, ok := err.(*os.PathError); ok {
-		if errno, ok := pe.Err.(syscall.Errno); ok {
-			// EACCES (permission denied) and EPERM (operation not permitted) are checked
-			return errno == syscall.EACCES || errno == syscall.EPERM
-		}
+	size, err := internal.DiskUsage(trashedFilePath)
+	if err != nil {
+		return fmt.Errorf("error calculating directory size: %w", err)
+	}
+	infoFileInfo, err := os.Stat(infoPath)
+	if err != nil {
+		return fmt.Errorf("error retrieving .trashinfo file info: %w", err)
+	}
+
+	sizes, err := wastebasket_nix.ReadDirectorySizes(trashDir)
+	if err != nil {
+		return err
+	}
+	sizes[filepath.Base(trashedFilePath)] = wastebasket_nix.DirectorySize{
+		Size:  size,
+		Mtime: infoFileInfo.ModTime().Unix(),
+	}
+	return wastebasket_nix.WriteDirectorySizes(trashDir, sizes)
+}
+
+// removeDirectorySize removes a directory from the directorysizes cache of
+// its trash directory, after it has been deleted or restored.
+func removeDirectorySize(trashDir, trashedFilePath string) error {
+	sizes, err := wastebasket_nix.ReadDirectorySizes(trashDir)
+	if err != nil {
+		return err
 	}
 
-	return false
+	name := filepath.Base(trashedFilePath)
+	if _, ok := sizes[name]; !ok {
+		return nil
+	}
+	delete(sizes, name)
+	return wastebasket_nix.WriteDirectorySizes(trashDir, sizes)
 }
 
 func Empty() error {
-	cache, err := getCache()
+	trashDirs, err := trashDirectories()
 	if err != nil {
-		return fmt.Errorf("error accessing cache: %w", err)
+		return err
 	}
+	return emptyTrashDirs(trashDirs)
+}
 
-	if err := internal.RemoveAllIfExists(cache.path); err != nil {
-		return fmt.Errorf("error clearing home trash '%s': %w", cache.path, err)
+// emptyScope returns a function reporting whether a trash directory is
+// selected by the options. If all trash directories are selected, nil is
+// returned.
+func emptyScope(options EmptyOptions) (func(trashDir string) bool, error) {
+	switch {
+	case options.HomeOnly:
+		cache, err := getCache()
+		if err != nil {
+			return nil, fmt.Errorf("error accessing cache: %w", err)
+		}
+		return func(trashDir string) bool {
+			return trashDir == cache.path
+		}, nil
+	case options.Mount != "":
+		mount, err := filepath.Abs(options.Mount)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving absolute filepath: %w", err)
+		}
+		mounts, err := internal.Mounts()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving mounts: %w", err)
+		}
+		if !slices.Contains(mounts, mount) {
+			return nil, fmt.Errorf("%w: '%s'", ErrUnknownMount, options.Mount)
+		}
+		currentUser, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("error getting current user: %w", err)
+		}
+		cache, err := getCache()
+		if err != nil {
+			return nil, fmt.Errorf("error accessing cache: %w", err)
+		}
+		// Just like on Windows, the home trash is part of the mount it is
+		// located on.
+		includesHome := cache.topdir == mount
+		return func(trashDir string) bool {
+			return (includesHome && trashDir == cache.path) ||
+				trashDir == filepath.Join(mount, ".Trash", currentUser.Uid) ||
+				trashDir == filepath.Join(mount, fmt.Sprintf(".Trash-%s", currentUser.Uid))
+		}, nil
 	}
+	return nil, nil
+}
 
-	mounts, err := internal.Mounts()
+// emptyScoped clears all trash directories selected by inScope.
+func emptyScoped(inScope func(trashDir string) bool) error {
+	trashDirs, err := trashDirectories()
 	if err != nil {
-		return fmt.Errorf("error retrieving mounts: %w", err)
+		return err
 	}
 
-	currentUser, err := user.Current()
+	trashDirs = slices.DeleteFunc(trashDirs, func(trashDir trashDirectory) bool {
+		return !inScope(trashDir.path)
+	})
+	return emptyTrashDirs(trashDirs)
+}
+
+// emptyWithReport clears all trash directories selected by inScope and
+// reports the outcome. If inScope is nil, all trash directories are cleared.
+func emptyWithReport(inScope func(trashDir string) bool) (*EmptyReport, error) {
+	trashDirs, err := trashDirectories()
 	if err != nil {
-		return fmt.Errorf("error getting current user: %w", err)
+		return nil, err
 	}
 
-	for _, mount := range mounts {
-		path := filepath.Join(mount, ".Trash", currentUser.Uid)
-		if err := internal.RemoveAllIfExists(path); err != nil && !isPermissionDenied(err) {
-			return fmt.Errorf("error clearing mount trash '%s': %w", path, err)
+	report := &EmptyReport{}
+	for _, trashDir := range trashDirs {
+		if inScope != nil && !inScope(trashDir.path) {
+			continue
+		}
+		if _, err := os.Lstat(trashDir.path); errors.Is(err, fs.ErrNotExist) {
+			continue
 		}
+		report.Results = append(report.Results, emptyTrashDir(trashDir, true))
+	}
+
+	report.sum()
+	return report, nil
+}
 
-		path = filepath.Join(mount, fmt.Sprintf(".Trash-%s", currentUser.Uid))
-		if err := internal.RemoveAllIfExists(path); err != nil && !isPermissionDenied(err) {
-			return fmt.Errorf("error clearing mount trash '%s': %w", path, err)
+// emptyTrashDirs clears the given trash directories. Entries we aren't
+// allowed to remove or that are located on a read-only filesystem are
+// skipped.
+func emptyTrashDirs(trashDirs []trashDirectory) error {
+	var errs []error
+	for _, trashDir := range trashDirs {
+		result := emptyTrashDir(trashDir, false)
+		for _, failure := range result.Failures {
+			// ENOTDIR happens on WSL when attempting to delete
+			// /mnt/wslg/versions.txt, which is weird considering that
+			// os.Remove can delete files.
+			if failure.Reason == FailureError && !errors.Is(failure.Err, syscall.ENOTDIR) {
+				errs = append(errs, fmt.Errorf("error clearing trash '%s': %w", trashDir.path, failure.Err))
+			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-func Query(options QueryOptions) (*QueryResult, error) {
-	if err := options.validate(); err != nil {
-		return nil, fmt.Errorf("error validating options: %w", err)
+// orphanGracePeriod is the minimum age of an info file without trashed file,
+// before it is considered orphaned.
+const orphanGracePeriod = time.Hour
+
+// emptyTrashDir deletes the content of a trash directory, but keeps the
+// directories themselves, so that their permissions stay intact and file
+// watchers don't lose track of them.
+//
+// Trashed files are deleted before their info files. This way, a failure
+// never leaves behind trashed files that can't be found anymore, but at most
+// orphaned info files, which are deleted on the next call. Since other
+// implementations write the info file before moving the file into the trash,
+// orphaned info files are only deleted once they are older than
+// orphanGracePeriod. Otherwise, we could break a concurrent trash operation.
+//
+// Calculating the freed bytes can be expensive for big directories, so it is
+// only done if measure is set.
+func emptyTrashDir(trashDir trashDirectory, measure bool) EmptyResult {
+	result := EmptyResult{TrashDir: trashDir.path}
+	fail := func(path string, err error) {
+		result.Failures = append(result.Failures, EmptyFailure{
+			Path:   path,
+			Reason: failureReason(err),
+			Err:    err,
+		})
 	}
 
-	cached, err := getCache()
-	if err != nil {
-		return nil, fmt.Errorf("error accessing cache: %w", err)
+	filesDir := filepath.Join(trashDir.path, "files")
+	infoDir := filepath.Join(trashDir.path, "info")
+	files, err := os.ReadDir(filesDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fail(filesDir, fmt.Errorf("error reading files directory: %w", err))
+		return result
+	}
+	var sizes wastebasket_nix.DirectorySizes
+	if measure && len(files) > 0 {
+		sizes, _ = wastebasket_nix.ReadDirectorySizes(trashDir.path)
+	}
+
+	removed := make(map[string]bool, len(files))
+	for _, file := range files {
+		trashedFile := filepath.Join(filesDir, file.Name())
+		infoPath := filepath.Join(infoDir, file.Name()+".trashinfo")
+
+		var size int64
+		if measure {
+			if fileInfo, err := file.Info(); err == nil {
+				size, _ = cachedTrashedFileSize(sizes, infoPath, trashedFile, fileInfo)
+			}
+		}
+
+		if err := os.RemoveAll(trashedFile); err != nil {
+			// Failures are reported using the original path, as the name
+			// inside of the trash is meaningless to the user.
+			path := trashedFile
+			if info, err := readTrashInfo(infoPath); err == nil {
+				path = info.Path
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(trashDir.baseDir, path)
+				}
+			}
+			fail(path, fmt.Errorf("error deleting trashed file: %w", err))
+			continue
+		}
+		removed[file.Name()] = true
+		result.Removed++
+		result.FreedBytes += size
+	}
+
+	infoFiles, err := os.ReadDir(infoDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fail(infoDir, fmt.Errorf("error reading info directory: %w", err))
+		return result
+	}
+	for _, infoFile := range infoFiles {
+		// Other implementations might be writing temporary files, which
+		// aren't ours to delete.
+		name, ok := strings.CutSuffix(infoFile.Name(), ".trashinfo")
+		if !ok || infoFile.IsDir() {
+			continue
+		}
+		if !removed[name] {
+			if _, err := os.Lstat(filepath.Join(filesDir, name)); !errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			fileInfo, err := infoFile.Info()
+			if err != nil || time.Since(fileInfo.ModTime()) < orphanGracePeriod {
+				continue
+			}
+		}
+		infoPath := filepath.Join(infoDir, infoFile.Name())
+		if err := os.Remove(infoPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fail(infoPath, fmt.Errorf("error deleting info file: %w", err))
+		}
 	}
 
-	result := &QueryResult{
-		Matches: make(map[string][]TrashedFileInfo),
+	if err := resetDirectorySizes(trashDir.path); err != nil {
+		fail(filepath.Join(trashDir.path, "directorysizes"), err)
+	}
+	return result
+}
+
+// isReadOnly checks whether the error was caused by a read-only filesystem.
+func isReadOnly(err error) bool {
+	return errors.Is(err, syscall.EROFS)
+}
+
+// resetDirectorySizes removes all directories that don't exist anymore from
+// the directorysizes cache. The file itself is kept.
+func resetDirectorySizes(trashDir string) error {
+	if _, err := os.Stat(filepath.Join(trashDir, "directorysizes")); errors.Is(err, fs.ErrNotExist) {
+		return nil
 	}
 
-	var matcher func(string) (string, bool)
-	if options.Glob {
-		globString := options.Search[0]
-		compiled, err := glob.Compile(globString)
+	sizes, err := wastebasket_nix.ReadDirectorySizes(trashDir)
+	if err != nil {
+		return err
+	}
+	for name := range sizes {
+		if _, err := os.Lstat(filepath.Join(trashDir, "files", name)); errors.Is(err, fs.ErrNotExist) {
+			delete(sizes, name)
+		}
+	}
+	return wastebasket_nix.WriteDirectorySizes(trashDir, sizes)
+}
+
+// query streams all matches of all trash directories. It stops on the first
+// error, except for *EntryError, which are yielded, but don't stop the query.
+func query(ctx context.Context, options QueryOptions) iter.Seq2[queryMatch, error] {
+	return func(yield func(queryMatch, error) bool) {
+		if err := options.validate(); err != nil {
+			yield(queryMatch{}, fmt.Errorf("error validating options: %w", err))
+			return
+		}
+
+		filter, err := newQueryFilter(options)
 		if err != nil {
-			return nil, fmt.Errorf("error compiling glob: %w", err)
+			yield(queryMatch{}, fmt.Errorf("error creating filter: %w", err))
+			return
 		}
-		matcher = func(s string) (string, bool) {
-			if compiled.Match(s) {
-				return globString, true
+
+		var patternMatcher func(string) (string, bool)
+		if options.matchMode() != MatchExact {
+			patternMatcher, err = newPatternMatcher(options)
+			if err != nil {
+				yield(queryMatch{}, fmt.Errorf("error creating matcher: %w", err))
+				return
 			}
-			return "", false
 		}
-	} else {
-		trashParent := filepath.Dir(cached.path)
-		matcher, err = relativePathMatcher(trashParent, options.Search)
+
+		trashDirs, err := trashDirectories()
 		if err != nil {
-			return nil, fmt.Errorf("error creating matcher: %w", err)
+			yield(queryMatch{}, err)
+			return
+		}
+
+		for _, trashDir := range trashDirs {
+			var matcher func(string) (string, bool)
+			switch {
+			case options.All:
+				// Without a search, the results are grouped by trash
+				// directory instead.
+				matcher = func(string) (string, bool) {
+					return trashDir.path, true
+				}
+			case patternMatcher != nil:
+				matcher = patternMatcher
+			default:
+				// Relative paths in topdir trashes are relative to the
+				// topdir, instead of the parent of the home trash.
+				matcher, err = relativePathMatcher(trashDir.baseDir, options.Search, options.CaseInsensitive)
+				if err != nil {
+					yield(queryMatch{}, fmt.Errorf("error creating matcher: %w", err))
+					return
+				}
+			}
+
+			if err := queryTrashDir(ctx, matcher, filter, trashDir.baseDir, trashDir.path, yield); err != nil {
+				if err != errStopped {
+					yield(queryMatch{}, fmt.Errorf("error querying trash '%s': %w", trashDir.path, err))
+				}
+				return
+			}
 		}
 	}
+}
+
+// trashDirectory is a trash directory that might contain files of the
+// current user.
+type trashDirectory struct {
+	path string
+	// baseDir is the directory relative paths in the .trashinfo files of
+	// this trash directory are relative to.
+	baseDir string
+}
 
-	if err := queryTrashDir(result, matcher, cached.dataHome, cached.path); err != nil {
-		return nil, fmt.Errorf("error querying home trash: %w", err)
+// trashDirectories returns the home trash, followed by all potential topdir
+// trashes of the mounted filesystems. The directories don't necessarily
+// exist.
+func trashDirectories() ([]trashDirectory, error) {
+	cached, err := getCache()
+	if err != nil {
+		return nil, fmt.Errorf("error accessing cache: %w", err)
 	}
 
 	mounts, err := internal.Mounts()
@@ -383,27 +812,18 @@ func Query(options QueryOptions) (*QueryResult, error) {
 		return nil, fmt.Errorf("error retrieving user data: %w", err)
 	}
 
+	trashDirs := []trashDirectory{{path: cached.path, baseDir: cached.dataHome}}
 	for _, mount := range mounts {
-		// Previously generated relative paths are for the home
-		// trash, therefore we need to regenerate them for the topdir
-		// trash, but reuse the slice for less shitty performance.
-		matcher, err = relativePathMatcher(mount, options.Search)
-		if err != nil {
-			return nil, fmt.Errorf("error creating matcher: %w", err)
-		}
-		if err := queryTrashDir(result, matcher, mount, filepath.Join(mount, ".Trash", u.Uid)); err != nil {
-			return nil, fmt.Errorf("error querying mount trash: %w", err)
-		}
-
-		if err := queryTrashDir(result, matcher, mount, filepath.Join(mount, fmt.Sprintf(".Trash-%s", u.Uid))); err != nil {
-			return nil, fmt.Errorf("error querying mount trash: %w", err)
-		}
+		trashDirs = append(trashDirs,
+			trashDirectory{path: filepath.Join(mount, ".Trash", u.Uid), baseDir: mount},
+			trashDirectory{path: filepath.Join(mount, fmt.Sprintf(".Trash-%s", u.Uid)), baseDir: mount},
+		)
 	}
 
-	return result, nil
+	return trashDirs, nil
 }
 
-func relativePathMatcher(base string, search []string) (func(string) (string, bool), error) {
+func relativePathMatcher(base string, search []string, caseInsensitive bool) (func(string) (string, bool), error) {
 	absPaths := make([]string, len(search))
 	relPaths := make([]string, len(search))
 	for index, path := range search {
@@ -420,9 +840,14 @@ func relativePathMatcher(base string, search []string) (func(string) (string, bo
 		}
 	}
 
+	equal := func(a, b string) bool { return a == b }
+	if caseInsensitive {
+		equal = strings.EqualFold
+	}
+
 	return func(s string) (string, bool) {
 		for i, path := range search {
-			if absPaths[i] == s || relPaths[i] == s {
+			if equal(absPaths[i], s) || equal(relPaths[i], s) {
 				return path, true
 			}
 		}
@@ -430,9 +855,21 @@ func relativePathMatcher(base string, search []string) (func(string) (string, bo
 	}, nil
 }
 
-func queryTrashDir(result *QueryResult, matcher func(string) (string, bool), baseDir, trashDir string) error {
+// queryTrashDir yields all matches of a single trash directory. If yield
+// returns false, errStopped is returned.
+func queryTrashDir(
+	ctx context.Context,
+	matcher func(string) (string, bool),
+	filter *queryFilter,
+	baseDir, trashDir string,
+	yield func(queryMatch, error) bool,
+) error {
 	infoDirectoryPath := filepath.Join(trashDir, "info")
 	err := filepath.WalkDir(infoDirectoryPath, func(infoPath string, dirEntry fs.DirEntry, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		if infoDirectoryPath == infoPath {
 			// No home trash means no files
 			if os.IsNotExist(err) || errors.Is(err, fs.ErrNotExist) {
@@ -445,57 +882,53 @@ func queryTrashDir(result *QueryResult, matcher func(string) (string, bool), bas
 		// Info dir shouldn't contain any dir, therefore we ignore this,
 		// as it should also not cause any further issues.
 		if dirEntry.IsDir() {
-			return nil
+			return filepath.SkipDir
 		}
-
-		bytes, err := os.ReadFile(infoPath)
-		if err != nil {
-			return fmt.Errorf("error reading .trashinfo file: %w", err)
-		}
-
-		// FIXME Is Fscanf more efficient?
-		// FIXME Are there generally more efficient stdlib ways to do this or should I manually parse?
-		var originalPath, deletionDateStr string
-		_, err = fmt.Sscanf(string(bytes), "[Trash Info]\nPath=%s\nDeletionDate=%s\n", &originalPath, &deletionDateStr)
-		if err != nil {
-			return fmt.Errorf("error parsing .trashinfo file: %w", err)
+		// Other implementations might leave temporary files behind.
+		if !strings.HasSuffix(infoPath, ".trashinfo") {
+			return nil
 		}
 
-		deletionDate, err := time.Parse(RFC3339, deletionDateStr)
+		// A single broken entry, possibly written by another
+		// implementation, shouldn't prevent us from querying the rest.
+		info, err := readTrashInfo(infoPath)
 		if err != nil {
-			return fmt.Errorf("error parsing deletion date: %w", err)
+			if !yield(queryMatch{}, &EntryError{InfoPath: infoPath, Err: err}) {
+				return errStopped
+			}
+			return nil
 		}
 
 		// If we saved a relative path, we need to join it together first, as
 		// our workdirectory might not match the directory the file resided in.
+		originalPath := info.Path
 		if !strings.HasPrefix(originalPath, "/") {
 			originalPath = filepath.Join(baseDir, originalPath)
 		}
 
 		// Hometrash supports both absolute paths and relative paths.
-		if input, matches := matcher(originalPath); matches {
-			trashedFile := filepath.Join(trashDir, "files", strings.TrimSuffix(filepath.Base(infoPath), ".trashinfo"))
-			trashInfo := wastebasket_nix.NewTrashedFileInfo(
-				originalPath,
-				deletionDate,
-				infoPath,
-				trashedFile,
-				func(force bool) error {
-					return restore(infoPath, trashedFile, originalPath, force)
-				},
-				func() error {
-					if err := os.Remove(infoPath); err != nil {
-						return fmt.Errorf("error removing .trashinfo at '%s': %w", infoPath, err)
-					}
-
-					if err := os.Remove(trashedFile); err != nil {
-						return fmt.Errorf("error removing trashed file at '%s': %w", trashedFile, err)
-					}
-
-					return nil
-				},
-			)
-			result.Matches[input] = append(result.Matches[input], trashInfo)
+		input, matches := matcher(originalPath)
+		if !matches || !filter.matchesMetadata(originalPath, info.DeletionDate) {
+			return nil
+		}
+
+		trashedFile := filepath.Join(trashDir, "files", strings.TrimSuffix(filepath.Base(infoPath), ".trashinfo"))
+		if filter.needsFile() {
+			matches, err := matchesTrashedFile(filter, trashDir, infoPath, trashedFile)
+			if err != nil {
+				if !yield(queryMatch{}, &EntryError{InfoPath: infoPath, Err: err}) {
+					return errStopped
+				}
+				return nil
+			}
+			if !matches {
+				return nil
+			}
+		}
+
+		trashInfo := newTrashedFileInfo(trashDir, infoPath, trashedFile, originalPath, info.DeletionDate)
+		if !yield(queryMatch{input: input, trashDir: trashDir, info: trashInfo}, nil) {
+			return errStopped
 		}
 
 		return nil
@@ -509,27 +942,167 @@ func queryTrashDir(result *QueryResult, matcher func(string) (string, bool), bas
 	return err
 }
 
-// It's probably preferable not to have a public Restore(...) function, as you
-// mostly will have to query first in order to delete anyways. Even then, a
-// restore with multiple files versions to restore would complicate the API.
-func restore(infoPath, trahedFilePath, originalPath string, force bool) error {
-	if !force {
-		info, err := os.Stat(originalPath)
-		if err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("error checking whether file exists: %w", err)
+// lookup reads a single entry, after making sure that the info file belongs
+// to one of the trash directories of the current user.
+func lookup(infoPath string) (TrashedFileInfo, error) {
+	if infoPath != filepath.Clean(infoPath) || !strings.HasSuffix(infoPath, ".trashinfo") {
+		return nil, ErrInvalidID
+	}
+
+	trashDirs, err := trashDirectories()
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(trashDirs, func(trashDir trashDirectory) bool {
+		return filepath.Dir(infoPath) == filepath.Join(trashDir.path, "info")
+	})
+	if index == -1 {
+		return nil, ErrInvalidID
+	}
+	trashDir := trashDirs[index]
+
+	info, err := readTrashInfo(infoPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
 		}
-		if info != nil {
-			return ErrAlreadyExists
+		return nil, &EntryError{InfoPath: infoPath, Err: err}
+	}
+
+	trashedFile := filepath.Join(trashDir.path, "files", strings.TrimSuffix(filepath.Base(infoPath), ".trashinfo"))
+	if _, err := os.Lstat(trashedFile); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
 		}
+		return nil, fmt.Errorf("error retrieving file info: %w", err)
 	}
-	if err := os.Rename(trahedFilePath, originalPath); err != nil {
-		// FIXME Use root error type that is public API
-		return fmt.Errorf("error restoring file '%s' to '%s'; .trashinfo path: '%s'", trahedFilePath, originalPath, infoPath)
+
+	originalPath := info.Path
+	if !strings.HasPrefix(originalPath, "/") {
+		originalPath = filepath.Join(trashDir.baseDir, originalPath)
 	}
 
-	if err := os.Remove(infoPath); err != nil {
-		return fmt.Errorf("error removing .trashinfo at '%s'; the file has been successfully restored though: %w", infoPath, err)
+	return newTrashedFileInfo(trashDir.path, infoPath, trashedFile, originalPath, info.DeletionDate), nil
+}
+
+// matchesTrashedFile applies the filters requiring access to the trashed file.
+func matchesTrashedFile(filter *queryFilter, trashDir, infoPath, trashedFile string) (bool, error) {
+	fileInfo, err := os.Lstat(trashedFile)
+	if err != nil {
+		return false, fmt.Errorf("error retrieving file info: %w", err)
 	}
+	return filter.matchesFile(fileInfo.Mode(), func() (int64, error) {
+		return trashedFileSize(trashDir, infoPath, trashedFile, fileInfo)
+	})
+}
 
-	return nil
+// trashedFileSize returns the size of a trashed file. The size of
+// directories is taken from the directorysizes cache if possible, otherwise
+// it is calculated the same way as for the cache.
+func trashedFileSize(trashDir, infoPath, trashedFile string, fileInfo fs.FileInfo) (int64, error) {
+	if !fileInfo.IsDir() {
+		return fileInfo.Size(), nil
+	}
+
+	sizes, _ := wastebasket_nix.ReadDirectorySizes(trashDir)
+	return cachedTrashedFileSize(sizes, infoPath, trashedFile, fileInfo)
+}
+
+// trashDirSizer returns a function measuring the files of the given trash
+// directory, reading the directorysizes cache only once for all of them.
+func trashDirSizer(trashDir string) func(TrashedFileInfo) (int64, error) {
+	sizes, _ := wastebasket_nix.ReadDirectorySizes(trashDir)
+	return func(info TrashedFileInfo) (int64, error) {
+		nixInfo, ok := info.(*wastebasket_nix.TrashedFileInfo)
+		if !ok {
+			return info.Size()
+		}
+		fileInfo, err := os.Lstat(nixInfo.CurrentPath())
+		if err != nil {
+			return 0, fmt.Errorf("error retrieving file info: %w", err)
+		}
+		return cachedTrashedFileSize(sizes, nixInfo.InfoPath(), nixInfo.CurrentPath(), fileInfo)
+	}
+}
+
+// cachedTrashedFileSize is the same as trashedFileSize, but uses the given
+// directorysizes cache, so it only has to be read once for many files. The
+// cache is merely an optimisation, so it may be nil if it couldn't be read.
+func cachedTrashedFileSize(sizes wastebasket_nix.DirectorySizes, infoPath, trashedFile string, fileInfo fs.FileInfo) (int64, error) {
+	if !fileInfo.IsDir() {
+		return fileInfo.Size(), nil
+	}
+
+	if cached, ok := sizes[filepath.Base(trashedFile)]; ok {
+		if infoFileInfo, err := os.Stat(infoPath); err == nil && infoFileInfo.ModTime().Unix() == cached.Mtime {
+			return cached.Size, nil
+		}
+	}
+
+	return internal.DiskUsage(trashedFile)
+}
+
+// newTrashedFileInfo creates the public representation of a file inside of
+// the given trash directory.
+func newTrashedFileInfo(
+	trashDir, infoPath, trashedFile, originalPath string,
+	deletionDate time.Time,
+) *wastebasket_nix.TrashedFileInfo {
+	return wastebasket_nix.NewTrashedFileInfo(
+		originalPath,
+		deletionDate,
+		infoPath,
+		trashedFile,
+		func(dest string, options RestoreOptions) error {
+			err := restoreFile(infoPath, trashedFile, dest, options)
+			// A failed merge might have already moved parts of the
+			// directory, invalidating the cached size. Skipped files
+			// haven't been touched at all.
+			if err == nil || (conflictPolicy(options) == ConflictMerge && !errors.Is(err, ErrSkipped)) {
+				_ = removeDirectorySize(trashDir, trashedFile)
+			}
+			return err
+		},
+		func() error {
+			// The trashed file is removed first, so that a partial failure
+			// doesn't leave behind data without metadata, see emptyTrashDir.
+			err := os.RemoveAll(trashedFile)
+			// Even a partial failure invalidates the cached size.
+			_ = removeDirectorySize(trashDir, trashedFile)
+			if err != nil {
+				return fmt.Errorf("error removing trashed file at '%s': %w", trashedFile, err)
+			}
+
+			if err := os.Remove(infoPath); err != nil {
+				return fmt.Errorf("error removing .trashinfo at '%s': %w", infoPath, err)
+			}
+			return nil
+		},
+		func() (int64, error) {
+			fileInfo, err := os.Lstat(trashedFile)
+			if err != nil {
+				return 0, fmt.Errorf("error retrieving file info: %w", err)
+			}
+			return trashedFileSize(trashDir, infoPath, trashedFile, fileInfo)
+		},
+		func(relative, dest string) error {
+			if err := restoreSubPath(trashedFile, relative, dest); err != nil {
+				return err
+			}
+
+			// The directory shrunk, so the cached size is recalculated.
+			_ = addDirectorySize(trashDir, trashedFile, infoPath)
+			return nil
+		},
+	)
+}
+
+func readTrashInfo(infoPath string) (trashinfo.Info, error) {
+	handle, err := os.Open(infoPath)
+	if err != nil {
+		return trashinfo.Info{}, fmt.Errorf("error opening .trashinfo file: %w", err)
+	}
+	defer handle.Close()
+
+	return trashinfo.Parse(handle)
 }
@@ -0,0 +1,121 @@
+package wastebasket_nix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Bios-Marcel/wastebasket/v2/trashinfo"
+)
+
+// DirectorySize is a single entry of the directorysizes cache, as defined by
+// the FreeDesktop Trash specification.
+type DirectorySize struct {
+	// Size is the disk space used by the directory and its content in bytes,
+	// calculated the same way as `du -B1` does.
+	Size int64
+	// Mtime is the modification time of the directories .trashinfo file in
+	// seconds since epoch. If it doesn't match the current modification time
+	// of the .trashinfo file, the entry is outdated.
+	Mtime int64
+}
+
+// DirectorySizes maps the names of trashed directories inside of
+// `$trash/files` to their cached size.
+type DirectorySizes map[string]DirectorySize
+
+// ReadDirectorySizes reads the `directorysizes` file of the given trash
+// directory. If the file doesn't exist, an empty cache is returned.
+func ReadDirectorySizes(trashDir string) (DirectorySizes, error) {
+	handle, err := os.Open(filepath.Join(trashDir, "directorysizes"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return make(DirectorySizes), nil
+		}
+		return nil, fmt.Errorf("error opening directorysizes: %w", err)
+	}
+	defer handle.Close()
+
+	return ParseDirectorySizes(handle)
+}
+
+// ParseDirectorySizes parses the content of a `directorysizes` file. Since
+// the file is merely a cache, invalid lines are skipped instead of failing.
+func ParseDirectorySizes(reader io.Reader) (DirectorySizes, error) {
+	sizes := make(DirectorySizes)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		mtime, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		name, err := trashinfo.UnescapePath(fields[2])
+		// Entries must be direct children of the files directory.
+		if err != nil || name == "" || strings.ContainsRune(name, '/') {
+			continue
+		}
+
+		sizes[name] = DirectorySize{Size: size, Mtime: mtime}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading directorysizes: %w", err)
+	}
+
+	return sizes, nil
+}
+
+// WriteDirectorySizes replaces the `directorysizes` file of the given trash
+// directory. As required by the spec, a temporary file is written first and
+// then atomically renamed, so concurrent writers can't corrupt the file.
+func WriteDirectorySizes(trashDir string, sizes DirectorySizes) error {
+	temp, err := os.CreateTemp(trashDir, "directorysizes.*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary directorysizes: %w", err)
+	}
+
+	names := make([]string, 0, len(sizes))
+	for name := range sizes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	writer := bufio.NewWriter(temp)
+	for _, name := range names {
+		size := sizes[name]
+		fmt.Fprintf(writer, "%d %d %s\n", size.Size, size.Mtime, trashinfo.EscapePath(name))
+	}
+
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return fmt.Errorf("error writing temporary directorysizes: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("error writing temporary directorysizes: %w", err)
+	}
+
+	if err := os.Rename(temp.Name(), filepath.Join(trashDir, "directorysizes")); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("error replacing directorysizes: %w", err)
+	}
+
+	return nil
+}
@@ -1,9 +1,11 @@
 package wastebasket_nix
 
 import (
-	"fmt"
-	"hash/fnv"
+	"io/fs"
+	"os"
 	"time"
+
+	"github.com/Bios-Marcel/wastebasket/v2/internal"
 )
 
 type TrashedFileInfo struct {
@@ -11,30 +13,36 @@ type TrashedFileInfo struct {
 	deletionDate time.Time
 
 	infoPath, currentPath string
-	restoreFunc           func(force bool) error
+	restoreFunc           func(dest string, options internal.RestoreOptions) error
 	deleteFunc            func() error
+	sizeFunc              func() (int64, error)
+	restorePathFunc       func(relative, dest string) error
 }
 
 func NewTrashedFileInfo(
 	originalPath string, deletionDate time.Time,
 	infoPath, currentPath string,
-	restoreFunc func(force bool) error,
+	restoreFunc func(dest string, options internal.RestoreOptions) error,
 	deleteFunc func() error,
+	sizeFunc func() (int64, error),
+	restorePathFunc func(relative, dest string) error,
 ) *TrashedFileInfo {
 	return &TrashedFileInfo{
-		originalPath: originalPath,
-		deletionDate: deletionDate,
-		infoPath:     infoPath,
-		currentPath:  currentPath,
-		restoreFunc:  restoreFunc,
-		deleteFunc:   deleteFunc,
+		originalPath:    originalPath,
+		deletionDate:    deletionDate,
+		infoPath:        infoPath,
+		currentPath:     currentPath,
+		restoreFunc:     restoreFunc,
+		deleteFunc:      deleteFunc,
+		sizeFunc:        sizeFunc,
+		restorePathFunc: restorePathFunc,
 	}
 }
 
+// UniqueIdentifier encodes the path of the info file, which can be decoded
+// again in order to look up the file.
 func (t TrashedFileInfo) UniqueIdentifier() string {
-	hash := fnv.New64()
-	hash.Write([]byte(t.infoPath))
-	return fmt.Sprintf("%x", hash.Sum(nil))
+	return internal.EncodeID(t.infoPath)
 }
 
 // OriginalPath is the files path before it was deleted.
@@ -61,9 +69,43 @@ func (t TrashedFileInfo) DeletionDate() time.Time {
 
 // Restore will attempt restoring the file to its previous location.
 func (t TrashedFileInfo) Restore(force bool) error {
-	return t.restoreFunc(force)
+	return t.restoreFunc(t.originalPath, internal.RestoreOptions{Force: force})
+}
+
+// RestoreTo will attempt restoring the file to the given destination.
+func (t TrashedFileInfo) RestoreTo(dest string, options internal.RestoreOptions) error {
+	return t.restoreFunc(dest, options)
 }
 
 func (t TrashedFileInfo) Delete() error {
 	return t.deleteFunc()
 }
+
+// Stat returns information about the file inside of the trashbin. Symlinks
+// aren't followed.
+func (t TrashedFileInfo) Stat() (fs.FileInfo, error) {
+	return os.Lstat(t.currentPath)
+}
+
+// Size is the size of the file in bytes. For directories, this is the disk
+// usage of the directory and its content, as defined by the directorysizes
+// cache of the trash specification.
+func (t TrashedFileInfo) Size() (int64, error) {
+	return t.sizeFunc()
+}
+
+// RestorePath restores a single file out of a trashed directory, leaving the
+// directory itself in the trash.
+func (t TrashedFileInfo) RestorePath(relative, dest string) error {
+	return t.restorePathFunc(relative, dest)
+}
+
+// Open opens the file inside of the trashbin for reading.
+func (t TrashedFileInfo) Open() (fs.File, error) {
+	return os.Open(t.currentPath)
+}
+
+// FS returns a filesystem rooted at the trashed directory.
+func (t TrashedFileInfo) FS() fs.FS {
+	return os.DirFS(t.currentPath)
+}
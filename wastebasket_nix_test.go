@@ -3,9 +3,18 @@
 package wastebasket_test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
 
 	"github.com/Bios-Marcel/wastebasket/v2"
+	"github.com/Bios-Marcel/wastebasket/v2/wastebasket_nix"
 )
 
 // TestTrashWithExistentFileWithDoubleQuotes tests trashing a single file with a double quote in its name
@@ -20,9 +29,502 @@ func Test_Trash_ExistentFileWithDoubleQuotes(t *testing.T) {
 	assertNotExists(t, path)
 }
 
+// /dev/shm is a tmpfs, but isn't considered a topdir, since all /dev/ mounts
+// are ignored. Therefore, files from there end up in the home trash, which
+// is on a different filesystem.
+func Test_Trash_CrossDevice(t *testing.T) {
+	if _, err := os.Stat("/dev/shm"); err != nil {
+		t.Skip("/dev/shm not available")
+	}
+
+	dir, err := os.MkdirTemp("/dev/shm", "wastebasket")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	folder := filepath.Join(dir, "folder")
+	require.NoError(t, os.Mkdir(folder, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "file.txt"), []byte("content"), 0o640))
+	require.NoError(t, os.Symlink("file.txt", filepath.Join(folder, "link")))
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	require.NoError(t, os.Chtimes(filepath.Join(folder, "file.txt"), modTime, modTime))
+
+	require.NoError(t, wastebasket.Trash(folder))
+	assertNotExists(t, folder)
+
+	result, err := wastebasket.Query(wastebasket.QueryOptions{Search: []string{folder}})
+	require.NoError(t, err)
+	require.Len(t, result.Matches[folder], 1)
+	match := result.Matches[folder][0]
+	t.Cleanup(func() { match.Delete() })
+
+	trashed := match.(*wastebasket_nix.TrashedFileInfo).CurrentPath()
+	folderInfo, err := os.Stat(trashed)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o750), folderInfo.Mode().Perm())
+
+	fileInfo, err := os.Stat(filepath.Join(trashed, "file.txt"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o640), fileInfo.Mode().Perm())
+	require.True(t, modTime.Equal(fileInfo.ModTime()))
+	content, err := os.ReadFile(filepath.Join(trashed, "file.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "content", string(content))
+
+	target, err := os.Readlink(filepath.Join(trashed, "link"))
+	require.NoError(t, err)
+	require.Equal(t, "file.txt", target)
+}
+
+func Test_RestoreTo_CrossDevice(t *testing.T) {
+	if _, err := os.Stat("/dev/shm"); err != nil {
+		t.Skip("/dev/shm not available")
+	}
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	folder := filepath.Join(home, "restore-cross-device")
+	t.Cleanup(writeTestData(t, folder+"/", filepath.Join(folder, "file.txt")))
+	infos, err := wastebasket.TrashAndReturn(folder)
+	require.NoError(t, err)
+	t.Cleanup(func() { infos[0].Delete() })
+
+	dir, err := os.MkdirTemp("/dev/shm", "wastebasket")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	dest := filepath.Join(dir, "restored")
+	require.NoError(t, infos[0].RestoreTo(dest, wastebasket.RestoreOptions{}))
+
+	content, err := os.ReadFile(filepath.Join(dest, "file.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "test", string(content))
+	assertNotExists(t, infos[0].(*wastebasket_nix.TrashedFileInfo).CurrentPath())
+	assertNotExists(t, infos[0].(*wastebasket_nix.TrashedFileInfo).InfoPath())
+}
+
+func Test_DirectorySizes(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	folder := filepath.Join(home, "sized-folder")
+	t.Cleanup(writeTestData(t, folder+"/", filepath.Join(folder, "file.txt")))
+	require.NoError(t, wastebasket.Trash(folder))
+
+	result, err := wastebasket.Query(wastebasket.QueryOptions{Search: []string{folder}})
+	require.NoError(t, err)
+	require.Len(t, result.Matches[folder], 1)
+	match := result.Matches[folder][0].(*wastebasket_nix.TrashedFileInfo)
+
+	trashDir := filepath.Dir(filepath.Dir(match.CurrentPath()))
+	sizes, err := wastebasket_nix.ReadDirectorySizes(trashDir)
+	require.NoError(t, err)
+	size, ok := sizes[filepath.Base(match.CurrentPath())]
+	require.True(t, ok)
+	require.Positive(t, size.Size)
+
+	infoStat, err := os.Stat(match.InfoPath())
+	require.NoError(t, err)
+	require.Equal(t, infoStat.ModTime().Unix(), size.Mtime)
+
+	// A skipped restore leaves the directory and its cached size untouched.
+	require.NoError(t, os.Mkdir(folder, 0o755))
+	err = match.RestoreTo(folder, wastebasket.RestoreOptions{OnConflict: wastebasket.ConflictSkip})
+	require.ErrorIs(t, err, wastebasket.ErrSkipped)
+	sizes, err = wastebasket_nix.ReadDirectorySizes(trashDir)
+	require.NoError(t, err)
+	require.Contains(t, sizes, filepath.Base(match.CurrentPath()))
+
+	require.NoError(t, match.Delete())
+	assertNotExists(t, match.CurrentPath())
+	sizes, err = wastebasket_nix.ReadDirectorySizes(trashDir)
+	require.NoError(t, err)
+	require.NotContains(t, sizes, filepath.Base(match.CurrentPath()))
+}
+
+func Test_TrashWithOptions(t *testing.T) {
+	if _, err := os.Stat("/dev/shm"); err != nil {
+		t.Skip("/dev/shm not available")
+	}
+
+	// Pipes can't be copied into the home trash, so this always fails.
+	dir, err := os.MkdirTemp("/dev/shm", "wastebasket")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	pipe := filepath.Join(dir, "pipe")
+	require.NoError(t, unix.Mkfifo(pipe, 0o600))
+
+	t.Run("stop_on_first_error", func(t *testing.T) {
+		t.Cleanup(writeTestData(t, "a.txt"))
+
+		report, err := wastebasket.TrashWithOptions(wastebasket.TrashOptions{}, pipe, "a.txt")
+		require.Error(t, err)
+		require.Len(t, report.Results, 1)
+		require.ErrorIs(t, err, report.Results[0].Err)
+		assertExists(t, pipe)
+		assertExists(t, "a.txt")
+	})
+
+	t.Run("continue_on_error", func(t *testing.T) {
+		t.Cleanup(writeTestData(t, "a.txt"))
+
+		report, err := wastebasket.TrashWithOptions(wastebasket.TrashOptions{ContinueOnError: true}, pipe, "a.txt", "doesntexist.txt")
+		require.Error(t, err)
+		require.Len(t, report.Results, 3)
+		assertExists(t, pipe)
+		assertNotExists(t, "a.txt")
+
+		require.Error(t, report.Results[0].Err)
+		require.Empty(t, report.Results[0].TrashDir)
+
+		trashed := report.Results[1]
+		require.NoError(t, trashed.Err)
+		require.Equal(t, "a.txt", trashed.Path)
+		assertExists(t, filepath.Join(trashed.TrashDir, "files", trashed.TrashedName))
+		assertExists(t, trashed.InfoPath)
+
+		require.Equal(t, wastebasket.TrashResult{Path: "doesntexist.txt"}, report.Results[2])
+	})
+}
+
+func homeTrash(t *testing.T) string {
+	t.Helper()
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		require.NoError(t, err)
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash")
+}
+
+// writeTrashEntry manually creates an entry in the home trash, simulating
+// other trash implementations.
+func writeTrashEntry(t *testing.T, name, trashInfo string) {
+	t.Helper()
+
+	trashDir := homeTrash(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(trashDir, "files"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(trashDir, "info"), 0o700))
+
+	filePath := filepath.Join(trashDir, "files", name)
+	infoPath := filepath.Join(trashDir, "info", name+".trashinfo")
+	require.NoError(t, os.WriteFile(filePath, []byte("content"), 0o600))
+	require.NoError(t, os.WriteFile(infoPath, []byte(trashInfo), 0o600))
+	t.Cleanup(func() {
+		os.Remove(filePath)
+		os.Remove(infoPath)
+	})
+}
+
+func Test_Query_TolerantTrashInfo(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	validEntries := map[string]string{
+		"crlf.txt":      "[Trash Info]\r\nPath=%s\r\nDeletionDate=2024-05-06T07:08:09\r\n",
+		"bom.txt":       "\uFEFF[Trash Info]\nPath=%s\nDeletionDate=2024-05-06T07:08:09\n",
+		"reordered.txt": "[Trash Info]\nDeletionDate=2024-05-06T07:08:09\nPath=%s\n",
+		"comments.txt":  "# Comment\n\n[Trash Info]\n# Another comment\nPath = %s\nDeletionDate = 2024-05-06T07:08:09\n",
+		"extra.txt":     "[Trash Info]\nPath=%s\nFoo=Bar\nDeletionDate=2024-05-06T07:08:09\nPath=/ignored\n[Other]\nPath=/ignored\n",
+		"timezone.txt":  "[Trash Info]\nPath=%s\nDeletionDate=2024-05-06T07:08:09+02:00\n",
+	}
+	for name, trashInfo := range validEntries {
+		writeTrashEntry(t, name, fmt.Sprintf(trashInfo, filepath.Join(home, name)))
+	}
+	writeTrashEntry(t, "no-header.txt", "Path=/no-header.txt\nDeletionDate=2024-05-06T07:08:09\n")
+	writeTrashEntry(t, "no-date.txt", "[Trash Info]\nPath=/no-date.txt\n")
+	writeTrashEntry(t, "invalid-date.txt", "[Trash Info]\nPath=/invalid-date.txt\nDeletionDate=yesterday\n")
+
+	var search []string
+	for name := range validEntries {
+		search = append(search, filepath.Join(home, name))
+	}
+	result, err := wastebasket.Query(wastebasket.QueryOptions{Search: search})
+	require.NoError(t, err)
+	for _, path := range search {
+		if assert.Len(t, result.Matches[path], 1, path) {
+			deletionDate := result.Matches[path][0].DeletionDate()
+			if filepath.Base(path) == "timezone.txt" {
+				assert.True(t, deletionDate.Equal(time.Date(2024, 5, 6, 5, 8, 9, 0, time.UTC)))
+			} else {
+				assert.Equal(t, time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local), deletionDate)
+			}
+		}
+	}
+
+	var brokenEntries []string
+	for _, err := range result.Errors {
+		var entryErr *wastebasket.EntryError
+		require.ErrorAs(t, err, &entryErr)
+		brokenEntries = append(brokenEntries, filepath.Base(entryErr.InfoPath))
+	}
+	// The trash might contain other broken entries, so we can't expect an
+	// exact match.
+	assert.Subset(t, brokenEntries, []string{
+		"no-header.txt.trashinfo",
+		"no-date.txt.trashinfo",
+		"invalid-date.txt.trashinfo",
+	}, brokenEntries)
+}
+
+func Test_Query_EscapedPaths(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		escapedName string
+	}{
+		{"my report.pdf", "my%20report.pdf"},
+		{"ünïcödé.txt", "%C3%BCn%C3%AFc%C3%B6d%C3%A9.txt"},
+		{"100%.txt", "100%25.txt"},
+		{"%20.txt", "%2520.txt"},
+		{"new\nline.txt", "new%0Aline.txt"},
+		{"a+b;c=d.txt", "a+b;c=d.txt"},
+	}
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(home, testCase.name)
+			t.Cleanup(writeTestData(t, path))
+
+			infos, err := wastebasket.TrashAndReturn(path)
+			require.NoError(t, err)
+			require.Len(t, infos, 1)
+			assertNotExists(t, path)
+
+			trashInfo, err := os.ReadFile(infos[0].(*wastebasket_nix.TrashedFileInfo).InfoPath())
+			require.NoError(t, err)
+			require.Contains(t, string(trashInfo), "/"+testCase.escapedName+"\n")
+
+			result, err := wastebasket.Query(wastebasket.QueryOptions{Search: []string{path}})
+			require.NoError(t, err)
+			require.Len(t, result.Matches[path], 1)
+			require.Equal(t, path, result.Matches[path][0].OriginalPath())
+
+			require.NoError(t, result.Matches[path][0].Restore(false))
+			assertExists(t, path)
+		})
+	}
+}
+
 // FIXME Write tests for:
 // * Restore on topdir of mount
 // * Restore of file with multiple versions
 // * Restore of file with multiple versions in different trashbins
 //   (technically not possible if only storing with wastebasket, but can happen technically on a system)
 // * Restore of nonexistent files
+
+func Test_EmptyWithOptions_OlderThan(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	old := filepath.Join(home, "empty-old.txt")
+	writeTrashEntry(t, "empty-old.txt", fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=2000-01-02T03:04:05\n", old))
+
+	recent := filepath.Join(home, "empty-recent.txt")
+	t.Cleanup(writeTestData(t, recent))
+	infos, err := wastebasket.TrashAndReturn(recent)
+	require.NoError(t, err)
+	t.Cleanup(func() { infos[0].Delete() })
+
+	// A negative duration mustn't be treated as no filter.
+	err = wastebasket.EmptyWithOptions(wastebasket.EmptyOptions{OlderThan: -time.Hour})
+	require.ErrorIs(t, err, wastebasket.ErrNegativeDuration)
+
+	require.NoError(t, wastebasket.EmptyWithOptions(wastebasket.EmptyOptions{OlderThan: 30 * 24 * time.Hour}))
+
+	result, err := wastebasket.Query(wastebasket.QueryOptions{Search: []string{old, recent}})
+	require.NoError(t, err)
+	require.Empty(t, result.Matches[old])
+	require.Len(t, result.Matches[recent], 1)
+}
+
+func Test_Prune_DryRun(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	old := filepath.Join(home, "prune-old.txt")
+	writeTrashEntry(t, "prune-old.txt", fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=2000-01-02T03:04:05\n", old))
+
+	_, err = wastebasket.Prune(wastebasket.PruneOptions{})
+	require.Error(t, err)
+
+	report, err := wastebasket.Prune(wastebasket.PruneOptions{MaxTotalBytes: 1, DryRun: true})
+	require.NoError(t, err)
+
+	var result *wastebasket.PruneResult
+	for index := range report.Results {
+		if report.Results[index].TrashDir == homeTrash(t) {
+			result = &report.Results[index]
+		}
+	}
+	require.NotNil(t, result)
+	require.NotEmpty(t, result.Deleted)
+	// The oldest entry is deleted first.
+	require.Equal(t, old, result.Deleted[0].OriginalPath())
+	require.GreaterOrEqual(t, result.FreedBytes, int64(len("content")))
+	require.Equal(t, result.TotalBytes, result.FreedBytes)
+
+	// Nothing may have been deleted.
+	query, err := wastebasket.Query(wastebasket.QueryOptions{Search: []string{old}})
+	require.NoError(t, err)
+	require.Len(t, query.Matches[old], 1)
+}
+
+func Test_EmptyWithOptions_Scopes(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	err = wastebasket.EmptyWithOptions(wastebasket.EmptyOptions{HomeOnly: true, Mount: "/"})
+	require.ErrorIs(t, err, wastebasket.ErrConflictingScopes)
+	err = wastebasket.EmptyWithOptions(wastebasket.EmptyOptions{Mount: filepath.Join(home, "no-mount")})
+	require.ErrorIs(t, err, wastebasket.ErrUnknownMount)
+
+	log := filepath.Join(home, "empty-scope.log")
+	writeTrashEntry(t, "empty-scope.log", fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=2024-05-06T07:08:09\n", log))
+	txt := filepath.Join(home, "empty-scope.txt")
+	writeTrashEntry(t, "empty-scope.txt", fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=2024-05-06T07:08:09\n", txt))
+
+	require.NoError(t, wastebasket.EmptyWithOptions(wastebasket.EmptyOptions{HomeOnly: true, Match: "*/empty-scope.log"}))
+
+	result, err := wastebasket.Query(wastebasket.QueryOptions{Search: []string{log, txt}})
+	require.NoError(t, err)
+	require.Empty(t, result.Matches[log])
+	require.Len(t, result.Matches[txt], 1)
+
+	// The home trash is located on "/", so it is emptied along with the
+	// topdir trash.
+	topdirTrash := filepath.Join("/", fmt.Sprintf(".Trash-%d", os.Getuid()))
+	if _, err := os.Stat(topdirTrash); err == nil {
+		t.Skip("topdir trash already in use")
+	}
+	if err := os.MkdirAll(filepath.Join(topdirTrash, "files"), 0o700); err != nil {
+		t.Skip("topdir trash not writable")
+	}
+	t.Cleanup(func() { os.RemoveAll(topdirTrash) })
+	require.NoError(t, os.MkdirAll(filepath.Join(topdirTrash, "info"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(topdirTrash, "files", "file.txt"), []byte("content"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(topdirTrash, "info", "file.txt.trashinfo"),
+		[]byte("[Trash Info]\nPath=file.txt\nDeletionDate=2024-05-06T07:08:09\n"), 0o600))
+
+	require.NoError(t, wastebasket.EmptyWithOptions(wastebasket.EmptyOptions{Mount: "/"}))
+
+	assertNotExists(t, filepath.Join(topdirTrash, "info", "file.txt.trashinfo"))
+	result, err = wastebasket.Query(wastebasket.QueryOptions{Search: []string{txt}})
+	require.NoError(t, err)
+	require.Empty(t, result.Matches[txt])
+}
+
+func Test_Empty_KeepsTrashDirectories(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	folder := filepath.Join(home, "empty-folder")
+	t.Cleanup(writeTestData(t, folder+"/", filepath.Join(folder, "file.txt")))
+	require.NoError(t, wastebasket.Trash(folder))
+
+	trashDir := homeTrash(t)
+	orphan := filepath.Join(trashDir, "info", "empty-orphan.trashinfo")
+	require.NoError(t, os.WriteFile(orphan, []byte("[Trash Info]\nPath=/orphan\nDeletionDate=2024-05-06T07:08:09\n"), 0o600))
+	past := time.Now().Add(-24 * time.Hour)
+	require.NoError(t, os.Chtimes(orphan, past, past))
+	// A fresh info file might belong to a file that is being trashed.
+	pending := filepath.Join(trashDir, "info", "empty-pending.trashinfo")
+	require.NoError(t, os.WriteFile(pending, []byte("[Trash Info]\nPath=/pending\nDeletionDate=2024-05-06T07:08:09\n"), 0o600))
+	t.Cleanup(func() { os.Remove(pending) })
+	// Temporary files of other implementations must be kept.
+	foreign := filepath.Join(trashDir, "info", "empty-foreign.tmp")
+	require.NoError(t, os.WriteFile(foreign, nil, 0o600))
+	t.Cleanup(func() { os.Remove(foreign) })
+
+	require.NoError(t, wastebasket.Empty())
+
+	for _, dir := range []string{trashDir, filepath.Join(trashDir, "files"), filepath.Join(trashDir, "info")} {
+		stat, err := os.Stat(dir)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o700), stat.Mode().Perm())
+	}
+
+	files, err := os.ReadDir(filepath.Join(trashDir, "files"))
+	require.NoError(t, err)
+	require.Empty(t, files)
+	assertNotExists(t, orphan)
+	assertExists(t, pending)
+	assertExists(t, foreign)
+
+	assertExists(t, filepath.Join(trashDir, "directorysizes"))
+	sizes, err := wastebasket_nix.ReadDirectorySizes(trashDir)
+	require.NoError(t, err)
+	require.Empty(t, sizes)
+}
+
+func Test_EmptyWithReport(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	require.NoError(t, wastebasket.Empty())
+
+	file := filepath.Join(home, "report.txt")
+	t.Cleanup(writeTestData(t, file))
+	require.NoError(t, wastebasket.Trash(file))
+
+	report, err := wastebasket.EmptyWithReport(wastebasket.EmptyOptions{HomeOnly: true})
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	require.Equal(t, homeTrash(t), report.Results[0].TrashDir)
+	require.Equal(t, 1, report.Results[0].Removed)
+	require.Equal(t, int64(len("test")), report.Results[0].FreedBytes)
+	require.Empty(t, report.Results[0].Failures)
+	require.Equal(t, 1, report.Removed)
+	require.Equal(t, int64(len("test")), report.FreedBytes)
+
+	// Selecting files deletes them one by one instead.
+	old := filepath.Join(home, "report-old.txt")
+	writeTrashEntry(t, "report-old.txt", fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=2000-01-02T03:04:05\n", old))
+
+	report, err = wastebasket.EmptyWithReport(wastebasket.EmptyOptions{OlderThan: 24 * time.Hour})
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	require.Equal(t, homeTrash(t), report.Results[0].TrashDir)
+	require.Equal(t, 1, report.Removed)
+	require.Equal(t, int64(len("content")), report.FreedBytes)
+}
+
+func Test_RestoreTo_OverwriteDirectory(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	folder := filepath.Join(home, "restore-overwrite")
+
+	// The existing directory is on a different filesystem if possible, so
+	// it can't simply be replaced via rename.
+	parents := []string{home}
+	if _, err := os.Stat("/dev/shm"); err == nil {
+		parents = append(parents, "/dev/shm")
+	}
+	for _, parent := range parents {
+		t.Cleanup(writeTestData(t, folder+"/", filepath.Join(folder, "file.txt")))
+		infos, err := wastebasket.TrashAndReturn(folder)
+		require.NoError(t, err)
+		t.Cleanup(func() { infos[0].Delete() })
+
+		dir, err := os.MkdirTemp(parent, "wastebasket")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		dest := filepath.Join(dir, "restored")
+		require.NoError(t, os.Mkdir(dest, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dest, "existing.txt"), []byte("existing"), 0o600))
+
+		require.NoError(t, infos[0].RestoreTo(dest, wastebasket.RestoreOptions{OnConflict: wastebasket.ConflictOverwrite}))
+		assertExists(t, filepath.Join(dest, "file.txt"))
+		assertNotExists(t, filepath.Join(dest, "existing.txt"))
+
+		// Nothing may be left behind, next to the restored directory.
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	}
+}
@@ -3,10 +3,18 @@
 package wastebasket_test
 
 import (
+	"context"
+	"encoding/base64"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -92,3 +100,646 @@ func Test_Query_Restore_Homedir(t *testing.T) {
 		assertExists(t, path)
 	})
 }
+
+func Test_TrashAndReturn(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	restorePath := filepath.Join(home, "restore.txt")
+	deletePath := filepath.Join(home, "delete.txt")
+	t.Cleanup(writeTestData(t, restorePath, deletePath))
+
+	infos, err := wastebasket.TrashAndReturn(restorePath, deletePath, filepath.Join(home, "doesntexist.txt"))
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assertNotExists(t, restorePath)
+	assertNotExists(t, deletePath)
+
+	require.Equal(t, restorePath, infos[0].OriginalPath())
+	require.NoError(t, infos[0].Restore(false))
+	assertExists(t, restorePath)
+
+	require.Equal(t, deletePath, infos[1].OriginalPath())
+	require.NoError(t, infos[1].Delete())
+	result, err := wastebasket.Query(wastebasket.QueryOptions{Search: []string{deletePath}})
+	require.NoError(t, err)
+	require.Empty(t, result.Matches[deletePath])
+}
+
+func Test_QueryIter(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	paths := []string{
+		filepath.Join(home, "iter-1.txt"),
+		filepath.Join(home, "iter-2.txt"),
+		filepath.Join(home, "iter-3.txt"),
+	}
+	t.Cleanup(writeTestData(t, paths...))
+	infos, err := wastebasket.TrashAndReturn(paths...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		for _, info := range infos {
+			info.Delete()
+		}
+	})
+
+	options := wastebasket.QueryOptions{Search: paths}
+	t.Run("all", func(t *testing.T) {
+		var found []string
+		for info, err := range wastebasket.QueryIter(context.Background(), options) {
+			require.NoError(t, err)
+			found = append(found, info.OriginalPath())
+		}
+		require.ElementsMatch(t, paths, found)
+	})
+
+	t.Run("early_break", func(t *testing.T) {
+		var count int
+		for _, err := range wastebasket.QueryIter(context.Background(), options) {
+			require.NoError(t, err)
+			count++
+			break
+		}
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var errs []error
+		for info, err := range wastebasket.QueryIter(ctx, options) {
+			require.Nil(t, info)
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+		require.ErrorIs(t, errs[0], context.Canceled)
+	})
+}
+
+func Test_List(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	paths := []string{
+		filepath.Join(home, "list-1.txt"),
+		filepath.Join(home, "list-2.txt"),
+	}
+	t.Cleanup(writeTestData(t, paths...))
+	infos, err := wastebasket.TrashAndReturn(paths...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		for _, info := range infos {
+			info.Delete()
+		}
+	})
+
+	result, err := wastebasket.List()
+	require.NoError(t, err)
+
+	// Both files are in the same trash directory, but there might be other
+	// files in there as well.
+	var trashDirs []string
+	for trashDir, matches := range result.Matches {
+		for _, match := range matches {
+			if slices.Contains(paths, match.OriginalPath()) {
+				trashDirs = append(trashDirs, trashDir)
+			}
+		}
+	}
+	require.Len(t, trashDirs, 2)
+	require.Equal(t, trashDirs[0], trashDirs[1])
+
+	_, err = wastebasket.Query(wastebasket.QueryOptions{All: true, Search: paths})
+	require.ErrorIs(t, err, wastebasket.ErrSearchWithAll)
+}
+
+func Test_Query_Filters(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	dir := filepath.Join(home, "query-filters")
+	require.NoError(t, os.Mkdir(dir, os.ModePerm))
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	small := filepath.Join(dir, "small.txt")
+	big := filepath.Join(dir, "big.txt")
+	sub := filepath.Join(dir, "sub")
+	writeTestData(t, small, sub+"/")
+	writeTestDataWith(t, strings.Repeat("a", 100), big)
+
+	infos, err := wastebasket.TrashAndReturn(small, big, sub)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		for _, info := range infos {
+			info.Delete()
+		}
+	})
+
+	query := func(t *testing.T, options wastebasket.QueryOptions) []string {
+		t.Helper()
+
+		options.All = true
+		options.Under = dir
+		result, err := wastebasket.Query(options)
+		require.NoError(t, err)
+
+		var found []string
+		for _, matches := range result.Matches {
+			for _, match := range matches {
+				found = append(found, match.OriginalPath())
+			}
+		}
+		return found
+	}
+
+	hourAgo := time.Now().Add(-time.Hour)
+	cases := []struct {
+		name     string
+		options  wastebasket.QueryOptions
+		expected []string
+	}{
+		{"under", wastebasket.QueryOptions{}, []string{small, big, sub}},
+		{"directories", wastebasket.QueryOptions{Type: wastebasket.FileTypeDirectory}, []string{sub}},
+		{"regular files", wastebasket.QueryOptions{Type: wastebasket.FileTypeRegular}, []string{small, big}},
+		{"min size", wastebasket.QueryOptions{Type: wastebasket.FileTypeRegular, MinSize: 50}, []string{big}},
+		{"max size", wastebasket.QueryOptions{Type: wastebasket.FileTypeRegular, MaxSize: 50}, []string{small}},
+		{"deleted after", wastebasket.QueryOptions{DeletedAfter: hourAgo}, []string{small, big, sub}},
+		{"deleted before", wastebasket.QueryOptions{DeletedBefore: hourAgo}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.ElementsMatch(t, c.expected, query(t, c.options))
+		})
+	}
+}
+
+func Test_Query_MatchModes(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	dir := filepath.Join(home, "query-match-modes")
+	require.NoError(t, os.Mkdir(dir, os.ModePerm))
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	first := filepath.Join(dir, "Match-Modes.TXT")
+	second := filepath.Join(dir, "match-other.txt")
+	writeTestData(t, first, second)
+
+	infos, err := wastebasket.TrashAndReturn(first, second)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		for _, info := range infos {
+			info.Delete()
+		}
+	})
+
+	cases := []struct {
+		name     string
+		options  wastebasket.QueryOptions
+		expected map[string][]string
+	}{
+		{
+			name: "multiple globs",
+			options: wastebasket.QueryOptions{
+				Match:  wastebasket.MatchGlob,
+				Search: []string{filepath.Join(dir, "Match*"), filepath.Join(dir, "*other*")},
+			},
+			expected: map[string][]string{
+				filepath.Join(dir, "Match*"):  {first},
+				filepath.Join(dir, "*other*"): {second},
+			},
+		},
+		{
+			name: "case insensitive regexp",
+			options: wastebasket.QueryOptions{
+				Match:           wastebasket.MatchRegexp,
+				CaseInsensitive: true,
+				Search:          []string{`match-modes\.txt$`},
+			},
+			expected: map[string][]string{`match-modes\.txt$`: {first}},
+		},
+		{
+			name: "substring",
+			options: wastebasket.QueryOptions{
+				Match:  wastebasket.MatchSubstring,
+				Search: []string{"other"},
+			},
+			expected: map[string][]string{"other": {second}},
+		},
+		{
+			name: "case insensitive basename",
+			options: wastebasket.QueryOptions{
+				Match:           wastebasket.MatchBasename,
+				CaseInsensitive: true,
+				Search:          []string{"MATCH-OTHER.TXT"},
+			},
+			expected: map[string][]string{"MATCH-OTHER.TXT": {second}},
+		},
+		{
+			name: "case insensitive exact",
+			options: wastebasket.QueryOptions{
+				CaseInsensitive: true,
+				Search:          []string{strings.ToLower(first)},
+			},
+			expected: map[string][]string{strings.ToLower(first): {first}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.options.Under = dir
+			result, err := wastebasket.Query(c.options)
+			require.NoError(t, err)
+
+			found := make(map[string][]string)
+			for pattern, matches := range result.Matches {
+				for _, match := range matches {
+					found[pattern] = append(found[pattern], match.OriginalPath())
+				}
+			}
+			require.Equal(t, c.expected, found)
+		})
+	}
+}
+
+func Test_TrashedFileInfo_StatAndSize(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	file := filepath.Join(home, "stat-file.txt")
+	dir := filepath.Join(home, "stat-dir")
+	t.Cleanup(writeTestData(t, file, dir+"/"))
+	writeTestDataWith(t, strings.Repeat("a", 100), filepath.Join(dir, "content.txt"))
+
+	infos, err := wastebasket.TrashAndReturn(file, dir)
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	t.Cleanup(func() {
+		for _, info := range infos {
+			info.Delete()
+		}
+	})
+
+	fileInfo, err := infos[0].Stat()
+	require.NoError(t, err)
+	require.True(t, fileInfo.Mode().IsRegular())
+	size, err := infos[0].Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(len("test")), size)
+
+	fileInfo, err = infos[1].Stat()
+	require.NoError(t, err)
+	require.True(t, fileInfo.IsDir())
+	// On Unix, this is the disk usage, on Windows the sum of the file sizes.
+	size, err = infos[1].Size()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, size, int64(100))
+}
+
+func Test_TrashedFileInfo_OpenAndFS(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	file := filepath.Join(home, "open-file.txt")
+	dir := filepath.Join(home, "open-dir")
+	t.Cleanup(writeTestData(t, file, dir+"/", filepath.Join(dir, "sub")+"/"))
+	writeTestDataWith(t, "content", filepath.Join(dir, "a.txt"), filepath.Join(dir, "sub", "b.txt"))
+
+	infos, err := wastebasket.TrashAndReturn(file, dir)
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	t.Cleanup(func() {
+		for _, info := range infos {
+			info.Delete()
+		}
+	})
+
+	handle, err := infos[0].Open()
+	require.NoError(t, err)
+	content, err := io.ReadAll(handle)
+	require.NoError(t, handle.Close())
+	require.NoError(t, err)
+	require.Equal(t, "test", string(content))
+
+	fsys := infos[1].FS()
+	require.NoError(t, fstest.TestFS(fsys, "a.txt", "sub/b.txt"))
+	content, err = fs.ReadFile(fsys, "sub/b.txt")
+	require.NoError(t, err)
+	require.Equal(t, "content", string(content))
+}
+
+func Test_FS(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	root := filepath.Join(home, "trash-fs")
+	require.NoError(t, os.Mkdir(root, os.ModePerm))
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	versioned := filepath.Join(root, "versioned.txt")
+	single := filepath.Join(root, "single.txt")
+	dir := filepath.Join(root, "dir")
+
+	var infos []wastebasket.TrashedFileInfo
+	t.Cleanup(func() {
+		for _, info := range infos {
+			info.Delete()
+		}
+	})
+	for _, content := range []string{"first", "second"} {
+		writeTestDataWith(t, content, versioned)
+		trashed, err := wastebasket.TrashAndReturn(versioned)
+		require.NoError(t, err)
+		infos = append(infos, trashed...)
+	}
+	writeTestData(t, single, dir+"/")
+	writeTestDataWith(t, "content", filepath.Join(dir, "file.txt"))
+	trashed, err := wastebasket.TrashAndReturn(single, dir)
+	require.NoError(t, err)
+	infos = append(infos, trashed...)
+
+	fsys, err := wastebasket.FS()
+	require.NoError(t, err)
+
+	// The volume name is kept without colon on windows.
+	rootName := strings.TrimPrefix(strings.ReplaceAll(filepath.ToSlash(root), ":", ""), "/")
+	sub, err := fs.Sub(fsys, rootName)
+	require.NoError(t, err)
+
+	expected := []string{
+		"versioned.txt@" + infos[0].UniqueIdentifier(),
+		"versioned.txt@" + infos[1].UniqueIdentifier(),
+		"single.txt",
+		"dir/file.txt",
+	}
+	require.NoError(t, fstest.TestFS(sub, expected...))
+
+	content, err := fs.ReadFile(sub, expected[1])
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+	content, err = fs.ReadFile(sub, "dir/file.txt")
+	require.NoError(t, err)
+	require.Equal(t, "content", string(content))
+}
+
+func Test_RestoreTo(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	file := filepath.Join(home, "restore-to.txt")
+	dest := filepath.Join(home, "restore-to-dest.txt")
+	t.Cleanup(writeTestData(t, file))
+	t.Cleanup(func() { os.Remove(dest) })
+
+	infos, err := wastebasket.TrashAndReturn(file)
+	require.NoError(t, err)
+	t.Cleanup(func() { infos[0].Delete() })
+
+	writeTestDataWith(t, "existing", dest)
+	require.ErrorIs(t, infos[0].RestoreTo(dest, wastebasket.RestoreOptions{}), wastebasket.ErrAlreadyExists)
+	require.NoError(t, infos[0].RestoreTo(dest, wastebasket.RestoreOptions{Force: true}))
+
+	assertNotExists(t, file)
+	content, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, "test", string(content))
+}
+
+func Test_Restore_MissingParents(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	dir := filepath.Join(home, "restore-parents")
+	file := filepath.Join(dir, "file.txt")
+	sibling := filepath.Join(dir, "sibling.txt")
+	t.Cleanup(writeTestData(t, dir+"/", file, sibling))
+
+	infos, err := wastebasket.TrashAndReturn(file)
+	require.NoError(t, err)
+	t.Cleanup(func() { infos[0].Delete() })
+
+	t.Run("missing_parent", func(t *testing.T) {
+		dest := filepath.Join(home, "restore-parents-missing", "file.txt")
+		err := infos[0].RestoreTo(dest, wastebasket.RestoreOptions{})
+
+		var restoreErr *wastebasket.RestoreError
+		require.ErrorAs(t, err, &restoreErr)
+		require.Equal(t, dest, restoreErr.Dest)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("trashed_parent", func(t *testing.T) {
+		dirInfos, err := wastebasket.TrashAndReturn(dir)
+		require.NoError(t, err)
+		t.Cleanup(func() { dirInfos[0].Delete() })
+
+		require.NoError(t, infos[0].RestoreTo(file, wastebasket.RestoreOptions{RestoreParents: true}))
+		assertExists(t, file)
+		assertExists(t, sibling)
+	})
+
+	t.Run("trashed_nested_parent", func(t *testing.T) {
+		// Only the nested directory is trashed, its parent is simply gone.
+		outer := filepath.Join(home, "restore-parents-outer")
+		inner := filepath.Join(outer, "inner")
+		file := filepath.Join(inner, "file.txt")
+		sibling := filepath.Join(inner, "sibling.txt")
+		t.Cleanup(writeTestData(t, outer+"/", inner+"/", file, sibling))
+
+		fileInfos, err := wastebasket.TrashAndReturn(file)
+		require.NoError(t, err)
+		t.Cleanup(func() { fileInfos[0].Delete() })
+		innerInfos, err := wastebasket.TrashAndReturn(inner)
+		require.NoError(t, err)
+		t.Cleanup(func() { innerInfos[0].Delete() })
+		require.NoError(t, os.RemoveAll(outer))
+
+		require.NoError(t, fileInfos[0].RestoreTo(file, wastebasket.RestoreOptions{RestoreParents: true}))
+		assertExists(t, file)
+		assertExists(t, sibling)
+	})
+
+	t.Run("create_parents", func(t *testing.T) {
+		infos, err := wastebasket.TrashAndReturn(file)
+		require.NoError(t, err)
+		t.Cleanup(func() { infos[0].Delete() })
+
+		created := filepath.Join(home, "restore-parents-created")
+		t.Cleanup(func() { os.RemoveAll(created) })
+
+		dest := filepath.Join(created, "nested", "file.txt")
+		require.NoError(t, infos[0].RestoreTo(dest, wastebasket.RestoreOptions{CreateParents: true}))
+		assertExists(t, dest)
+	})
+}
+
+func Test_Restore_ConflictPolicies(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	// trashConflicting trashes a file and then creates a new file at the
+	// same location.
+	trashConflicting := func(t *testing.T, path string, existingModTime time.Time) wastebasket.TrashedFileInfo {
+		t.Helper()
+
+		t.Cleanup(writeTestData(t, path))
+		infos, err := wastebasket.TrashAndReturn(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { infos[0].Delete() })
+
+		writeTestDataWith(t, "existing", path)
+		require.NoError(t, os.Chtimes(path, existingModTime, existingModTime))
+		return infos[0]
+	}
+	assertContent := func(t *testing.T, path, expected string) {
+		t.Helper()
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(content))
+	}
+
+	path := filepath.Join(home, "conflict.txt")
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	t.Run("fail", func(t *testing.T) {
+		info := trashConflicting(t, path, past)
+		err := info.RestoreTo(path, wastebasket.RestoreOptions{OnConflict: wastebasket.ConflictFail})
+		require.ErrorIs(t, err, wastebasket.ErrAlreadyExists)
+		assertContent(t, path, "existing")
+	})
+	t.Run("overwrite", func(t *testing.T) {
+		info := trashConflicting(t, path, future)
+		require.NoError(t, info.RestoreTo(path, wastebasket.RestoreOptions{OnConflict: wastebasket.ConflictOverwrite}))
+		assertContent(t, path, "test")
+	})
+	t.Run("skip", func(t *testing.T) {
+		info := trashConflicting(t, path, past)
+		err := info.RestoreTo(path, wastebasket.RestoreOptions{OnConflict: wastebasket.ConflictSkip})
+		require.ErrorIs(t, err, wastebasket.ErrSkipped)
+		assertContent(t, path, "existing")
+		_, err = info.Stat()
+		require.NoError(t, err)
+	})
+	t.Run("keep_both", func(t *testing.T) {
+		info := trashConflicting(t, path, past)
+		kept := filepath.Join(home, "conflict (restored 1).txt")
+		t.Cleanup(func() { os.Remove(kept) })
+
+		require.NoError(t, info.RestoreTo(path, wastebasket.RestoreOptions{OnConflict: wastebasket.ConflictKeepBoth}))
+		assertContent(t, path, "existing")
+		assertContent(t, kept, "test")
+	})
+	t.Run("overwrite_if_older", func(t *testing.T) {
+		info := trashConflicting(t, path, past)
+		require.NoError(t, info.RestoreTo(path, wastebasket.RestoreOptions{OnConflict: wastebasket.ConflictOverwriteIfOlder}))
+		assertContent(t, path, "test")
+	})
+	t.Run("overwrite_if_older_newer", func(t *testing.T) {
+		info := trashConflicting(t, path, future)
+		err := info.RestoreTo(path, wastebasket.RestoreOptions{OnConflict: wastebasket.ConflictOverwriteIfOlder})
+		require.ErrorIs(t, err, wastebasket.ErrSkipped)
+		assertContent(t, path, "existing")
+	})
+	t.Run("merge", func(t *testing.T) {
+		dir := filepath.Join(home, "conflict-merge")
+		t.Cleanup(writeTestData(t, dir+"/", filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")))
+		infos, err := wastebasket.TrashAndReturn(dir)
+		require.NoError(t, err)
+		t.Cleanup(func() { infos[0].Delete() })
+
+		writeTestData(t, dir+"/")
+		writeTestDataWith(t, "existing", filepath.Join(dir, "b.txt"), filepath.Join(dir, "c.txt"))
+
+		options := wastebasket.RestoreOptions{OnConflict: wastebasket.ConflictMerge}
+		require.ErrorIs(t, infos[0].RestoreTo(dir, options), wastebasket.ErrAlreadyExists)
+		assertContent(t, filepath.Join(dir, "a.txt"), "test")
+		assertContent(t, filepath.Join(dir, "b.txt"), "existing")
+		assertContent(t, filepath.Join(dir, "c.txt"), "existing")
+
+		// Without conflicts, the rest of the directory is restored.
+		require.NoError(t, os.Remove(filepath.Join(dir, "b.txt")))
+		require.NoError(t, infos[0].RestoreTo(dir, options))
+		assertContent(t, filepath.Join(dir, "b.txt"), "test")
+		_, err = infos[0].Stat()
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func Test_RestorePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	project := filepath.Join(home, "restore-path")
+	file := filepath.Join(project, "src", "main.go")
+	other := filepath.Join(project, "other.txt")
+	t.Cleanup(writeTestData(t, project+"/", filepath.Join(project, "src")+"/", file, other))
+
+	infos, err := wastebasket.TrashAndReturn(project)
+	require.NoError(t, err)
+	t.Cleanup(func() { infos[0].Delete() })
+
+	sizeBefore, err := infos[0].Size()
+	require.NoError(t, err)
+
+	var restoreErr *wastebasket.RestoreError
+	require.ErrorAs(t, infos[0].RestorePath(filepath.Join("..", "escape.txt"), file), &restoreErr)
+
+	require.NoError(t, infos[0].RestorePath(filepath.Join("src", "main.go"), file))
+	assertExists(t, file)
+	assertNotExists(t, other)
+	require.ErrorIs(t, infos[0].RestorePath(filepath.Join("src", "main.go"), file+".copy"), fs.ErrNotExist)
+
+	// The directory stays in the trash, but without the restored file.
+	_, err = fs.Stat(infos[0].FS(), "other.txt")
+	require.NoError(t, err)
+	_, err = fs.Stat(infos[0].FS(), "src/main.go")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	sizeAfter, err := infos[0].Size()
+	require.NoError(t, err)
+	require.Less(t, sizeAfter, sizeBefore)
+}
+
+func Test_Lookup_RestoreByID(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	path := filepath.Join(home, "lookup.txt")
+	var infos []wastebasket.TrashedFileInfo
+	t.Cleanup(func() {
+		for _, info := range infos {
+			info.Delete()
+		}
+	})
+	for _, content := range []string{"first", "second"} {
+		t.Cleanup(writeTestDataWith(t, content, path))
+		trashed, err := wastebasket.TrashAndReturn(path)
+		require.NoError(t, err)
+		infos = append(infos, trashed...)
+	}
+	require.NotEqual(t, infos[0].UniqueIdentifier(), infos[1].UniqueIdentifier())
+
+	for _, info := range infos {
+		found, err := wastebasket.Lookup(info.UniqueIdentifier())
+		require.NoError(t, err)
+		require.Equal(t, info.OriginalPath(), found.OriginalPath())
+		require.Equal(t, info.UniqueIdentifier(), found.UniqueIdentifier())
+	}
+
+	_, err = wastebasket.Lookup("not base64!")
+	require.ErrorIs(t, err, wastebasket.ErrInvalidID)
+	// Arbitrary files outside of the trash mustn't be accessible.
+	_, err = wastebasket.Lookup(base64.RawURLEncoding.EncodeToString([]byte(path)))
+	require.ErrorIs(t, err, wastebasket.ErrInvalidID)
+
+	require.NoError(t, wastebasket.RestoreByID(infos[1].UniqueIdentifier(), wastebasket.RestoreOptions{}))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+
+	_, err = wastebasket.Lookup(infos[1].UniqueIdentifier())
+	require.ErrorIs(t, err, wastebasket.ErrNotFound)
+}
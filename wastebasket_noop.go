@@ -2,14 +2,53 @@
 
 package wastebasket
 
+import (
+	"context"
+	"iter"
+)
+
 func Query(options QueryOptions) (*QueryResult, error) {
 	return nil, ErrPlatformNotSupported
 }
 
+func Lookup(id string) (TrashedFileInfo, error) {
+	return nil, ErrPlatformNotSupported
+}
+
+func RestoreByID(id string, options RestoreOptions) error {
+	return ErrPlatformNotSupported
+}
+
+func QueryIter(ctx context.Context, options QueryOptions) iter.Seq2[TrashedFileInfo, error] {
+	return func(yield func(TrashedFileInfo, error) bool) {
+		yield(nil, ErrPlatformNotSupported)
+	}
+}
+
 func Trash(paths ...string) error {
 	return ErrPlatformNotSupported
 }
 
+func TrashWithOptions(options TrashOptions, paths ...string) (*TrashReport, error) {
+	return nil, ErrPlatformNotSupported
+}
+
+func TrashAndReturn(paths ...string) ([]TrashedFileInfo, error) {
+	return nil, ErrPlatformNotSupported
+}
+
 func Empty() error {
 	return ErrPlatformNotSupported
 }
+
+func EmptyWithOptions(options EmptyOptions) error {
+	return ErrPlatformNotSupported
+}
+
+func EmptyWithReport(options EmptyOptions) (*EmptyReport, error) {
+	return nil, ErrPlatformNotSupported
+}
+
+func Prune(options PruneOptions) (*PruneReport, error) {
+	return nil, ErrPlatformNotSupported
+}
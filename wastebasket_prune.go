@@ -0,0 +1,88 @@
+//go:build freebsd || openbsd || netbsd || linux || windows
+
+package wastebasket
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Prune permanently deletes the oldest files of each trash directory, until
+// its size is below the given maximum. Files that can't be deleted are
+// skipped, all errors are returned at once, alongside the report.
+func Prune(options PruneOptions) (*PruneReport, error) {
+	if options.MaxTotalBytes <= 0 {
+		return nil, errors.New("max total bytes must be positive")
+	}
+
+	result, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	trashDirs := make([]string, 0, len(result.Matches))
+	for trashDir := range result.Matches {
+		trashDirs = append(trashDirs, trashDir)
+	}
+	slices.Sort(trashDirs)
+
+	report := &PruneReport{}
+	var errs []error
+	for _, trashDir := range trashDirs {
+		pruneResult, err := pruneTrashDir(trashDir, result.Matches[trashDir], options)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		report.Results = append(report.Results, pruneResult)
+		report.FreedBytes += pruneResult.FreedBytes
+	}
+
+	return report, errors.Join(errs...)
+}
+
+func pruneTrashDir(trashDir string, files []TrashedFileInfo, options PruneOptions) (PruneResult, error) {
+	result := PruneResult{TrashDir: trashDir}
+
+	type sizedFile struct {
+		info TrashedFileInfo
+		size int64
+	}
+
+	var errs []error
+	sizer := trashDirSizer(trashDir)
+	sizedFiles := make([]sizedFile, 0, len(files))
+	for _, file := range files {
+		size, err := sizer(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error calculating size of '%s': %w", file.OriginalPath(), err))
+			continue
+		}
+		sizedFiles = append(sizedFiles, sizedFile{info: file, size: size})
+		result.TotalBytes += size
+	}
+
+	slices.SortFunc(sizedFiles, func(a, b sizedFile) int {
+		return a.info.DeletionDate().Compare(b.info.DeletionDate())
+	})
+
+	remaining := result.TotalBytes
+	for _, file := range sizedFiles {
+		if remaining <= options.MaxTotalBytes {
+			break
+		}
+
+		if !options.DryRun {
+			if err := file.info.Delete(); err != nil {
+				errs = append(errs, fmt.Errorf("error deleting '%s': %w", file.info.OriginalPath(), err))
+				continue
+			}
+		}
+
+		result.Deleted = append(result.Deleted, file.info)
+		result.FreedBytes += file.size
+		remaining -= file.size
+	}
+
+	return result, errors.Join(errs...)
+}
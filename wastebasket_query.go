@@ -0,0 +1,228 @@
+//go:build freebsd || openbsd || netbsd || linux || windows
+
+package wastebasket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Bios-Marcel/wastebasket/v2/internal"
+	"github.com/gobwas/glob"
+)
+
+// queryMatch is a single match of a query, including the search input it
+// matched.
+type queryMatch struct {
+	input string
+	// trashDir is the trash directory containing the file.
+	trashDir string
+	info     TrashedFileInfo
+}
+
+// errStopped signals that the consumer of an iterator stopped iterating.
+var errStopped = errors.New("iteration stopped")
+
+// Query searches all trash directories for the given files. The whole
+// result is kept in memory, for big trash directories, consider using
+// QueryIter instead.
+func Query(options QueryOptions) (*QueryResult, error) {
+	result := &QueryResult{
+		Matches: make(map[string][]TrashedFileInfo),
+	}
+
+	for match, err := range query(context.Background(), options) {
+		if err != nil {
+			var entryErr *EntryError
+			if errors.As(err, &entryErr) {
+				result.Errors = append(result.Errors, err)
+				continue
+			}
+			return nil, err
+		}
+
+		result.Matches[match.input] = append(result.Matches[match.input], match.info)
+	}
+
+	return result, nil
+}
+
+// QueryIter is the same as Query, but yields the trashed files as soon as
+// they are found, instead of collecting them first. Entries that can't be
+// read are yielded as *EntryError and the iteration continues. Any other
+// error ends the iteration, this includes the cancellation of ctx.
+func QueryIter(ctx context.Context, options QueryOptions) iter.Seq2[TrashedFileInfo, error] {
+	return func(yield func(TrashedFileInfo, error) bool) {
+		for match, err := range query(ctx, options) {
+			if !yield(match.info, err) {
+				return
+			}
+		}
+	}
+}
+
+// Lookup returns the trashed file with the given unique identifier. As the
+// identifier encodes the location of the files metadata, only this single
+// entry is read, instead of querying the whole trash.
+func Lookup(id string) (TrashedFileInfo, error) {
+	infoPath, err := internal.DecodeID(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidID, err)
+	}
+	return lookup(infoPath)
+}
+
+// RestoreByID restores the trashed file with the given unique identifier to
+// its original location.
+func RestoreByID(id string, options RestoreOptions) error {
+	info, err := Lookup(id)
+	if err != nil {
+		return err
+	}
+	return info.RestoreTo(info.OriginalPath(), options)
+}
+
+// newPatternMatcher creates a matcher for all match modes, except for
+// MatchExact, as exact matching depends on the trash directory. The matcher
+// returns the first pattern that matches the given path.
+func newPatternMatcher(options QueryOptions) (func(string) (string, bool), error) {
+	matchers := make([]func(string) bool, len(options.Search))
+	for index, pattern := range options.Search {
+		if options.CaseInsensitive && options.matchMode() != MatchRegexp {
+			pattern = strings.ToLower(pattern)
+		}
+
+		switch options.matchMode() {
+		case MatchGlob:
+			compiled, err := glob.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("error compiling glob: %w", err)
+			}
+			matchers[index] = compiled.Match
+		case MatchRegexp:
+			if options.CaseInsensitive {
+				pattern = "(?i)" + pattern
+			}
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("error compiling regexp: %w", err)
+			}
+			matchers[index] = compiled.MatchString
+		case MatchSubstring:
+			matchers[index] = func(path string) bool {
+				return strings.Contains(path, pattern)
+			}
+		case MatchBasename:
+			matchers[index] = func(path string) bool {
+				return filepath.Base(path) == pattern
+			}
+		default:
+			return nil, ErrInvalidMatchMode
+		}
+	}
+
+	return func(path string) (string, bool) {
+		if options.CaseInsensitive && options.matchMode() != MatchRegexp {
+			path = strings.ToLower(path)
+		}
+		for index, matcher := range matchers {
+			if matcher(path) {
+				return options.Search[index], true
+			}
+		}
+		return "", false
+	}, nil
+}
+
+// queryFilter applies the filters of QueryOptions to the entries matched by
+// the search.
+type queryFilter struct {
+	options QueryOptions
+	under   string
+}
+
+func newQueryFilter(options QueryOptions) (*queryFilter, error) {
+	filter := &queryFilter{options: options}
+	if options.Under != "" {
+		under, err := filepath.Abs(options.Under)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving absolute filepath: %w", err)
+		}
+		filter.under = under
+	}
+	return filter, nil
+}
+
+// matchesMetadata checks all filters that only require the metadata of an
+// entry.
+func (filter *queryFilter) matchesMetadata(originalPath string, deletionDate time.Time) bool {
+	if !filter.options.DeletedAfter.IsZero() && deletionDate.Before(filter.options.DeletedAfter) {
+		return false
+	}
+	if !filter.options.DeletedBefore.IsZero() && !deletionDate.Before(filter.options.DeletedBefore) {
+		return false
+	}
+	if filter.under != "" && !isInside(filter.under, originalPath) {
+		return false
+	}
+	return true
+}
+
+// needsFile indicates whether matchesFile has to be called, which requires
+// accessing the trashed file.
+func (filter *queryFilter) needsFile() bool {
+	return filter.options.Type != FileTypeAny ||
+		filter.options.MinSize > 0 ||
+		filter.options.MaxSize > 0
+}
+
+// matchesFile checks all filters that require accessing the trashed file.
+// Since calculating the size can be expensive, size is only called if a size
+// filter is set.
+func (filter *queryFilter) matchesFile(mode fs.FileMode, size func() (int64, error)) (bool, error) {
+	switch filter.options.Type {
+	case FileTypeRegular:
+		if !mode.IsRegular() {
+			return false, nil
+		}
+	case FileTypeDirectory:
+		if !mode.IsDir() {
+			return false, nil
+		}
+	case FileTypeSymlink:
+		if mode&fs.ModeSymlink == 0 {
+			return false, nil
+		}
+	}
+
+	if filter.options.MinSize > 0 || filter.options.MaxSize > 0 {
+		size, err := size()
+		if err != nil {
+			return false, fmt.Errorf("error calculating size: %w", err)
+		}
+		if size < filter.options.MinSize {
+			return false, nil
+		}
+		if filter.options.MaxSize > 0 && size > filter.options.MaxSize {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// isInside checks whether path is located inside of dir. Both paths have to
+// be absolute.
+func isInside(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
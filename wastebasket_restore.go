@@ -0,0 +1,350 @@
+//go:build freebsd || openbsd || netbsd || linux || windows
+
+package wastebasket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Bios-Marcel/wastebasket/v2/internal"
+)
+
+// It's probably preferable not to have a public Restore(...) function, as you
+// mostly will have to query first in order to delete anyways. Even then, a
+// restore with multiple files versions to restore would complicate the API.
+
+// restoreFile moves a trashed file to dest and removes its metadata file
+// afterwards. If dest is on a different filesystem than the trash, the file
+// is copied instead. All errors are returned as *RestoreError.
+func restoreFile(infoPath, trashedFilePath, dest string, options RestoreOptions) error {
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		err = fmt.Errorf("error retrieving absolute filepath: %w", err)
+	} else {
+		err = restoreFileTo(infoPath, trashedFilePath, absDest, options)
+	}
+
+	if err != nil {
+		return &RestoreError{Path: trashedFilePath, Dest: dest, Err: err}
+	}
+	return nil
+}
+
+func restoreFileTo(infoPath, trashedFilePath, dest string, options RestoreOptions) error {
+	// Existing files are only replaced once the trashed file has been moved
+	// successfully, so a failed restore doesn't lose them.
+	var replaceExisting bool
+	if destInfo, err := os.Lstat(dest); err == nil {
+		trashedInfo, err := os.Lstat(trashedFilePath)
+		if err != nil {
+			return fmt.Errorf("error retrieving file info: %w", err)
+		}
+
+		switch conflictPolicy(options) {
+		case ConflictSkip:
+			return ErrSkipped
+		case ConflictOverwriteIfOlder:
+			if !destInfo.ModTime().Before(trashedInfo.ModTime()) {
+				return ErrSkipped
+			}
+			replaceExisting = true
+		case ConflictOverwrite:
+			replaceExisting = true
+		case ConflictKeepBoth:
+			dest, err = keepBothPath(dest, trashedInfo.IsDir())
+			if err != nil {
+				return err
+			}
+		case ConflictMerge:
+			if !destInfo.IsDir() || !trashedInfo.IsDir() {
+				return ErrAlreadyExists
+			}
+			if err := mergeDirectory(trashedFilePath, dest); err != nil {
+				return err
+			}
+			if err := os.Remove(infoPath); err != nil {
+				return fmt.Errorf("error removing info file at '%s'; the file has been successfully restored though: %w", infoPath, err)
+			}
+			return nil
+		default:
+			return ErrAlreadyExists
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error checking whether file exists: %w", err)
+	}
+
+	if options.RestoreParents {
+		if err := restoreParents(dest); err != nil {
+			return fmt.Errorf("error restoring parent directories: %w", err)
+		}
+	}
+	if options.CreateParents {
+		// The permissions are further restricted by the umask.
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return fmt.Errorf("error creating parent directories: %w", err)
+		}
+	}
+
+	var removeReplaced func() error
+	var err error
+	if replaceExisting {
+		removeReplaced, err = replaceFile(trashedFilePath, dest)
+	} else {
+		err = internal.MoveAll(trashedFilePath, dest)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(infoPath); err != nil {
+		return fmt.Errorf("error removing info file at '%s'; the file has been successfully restored though: %w", infoPath, err)
+	}
+
+	if removeReplaced != nil {
+		if err := removeReplaced(); err != nil {
+			return fmt.Errorf("error removing replaced file; the file has been successfully restored though: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// replaceFile moves src to the existing dest. Files on the same filesystem
+// are replaced atomically via rename. Otherwise, dest is moved aside and only
+// removed once src has been moved successfully, for which a function is
+// returned. If the move fails, dest is moved back.
+func replaceFile(src, dest string) (func() error, error) {
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving file info: %w", err)
+	}
+	destInfo, err := os.Lstat(dest)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving file info: %w", err)
+	}
+
+	if !srcInfo.IsDir() && !destInfo.IsDir() {
+		err := os.Rename(src, dest)
+		if err == nil || !internal.IsCrossDevice(err) {
+			return nil, err
+		}
+	}
+
+	// A fresh directory guarantees that the name isn't taken.
+	asideDir, err := os.MkdirTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".replaced-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary directory: %w", err)
+	}
+	aside := filepath.Join(asideDir, filepath.Base(dest))
+	if err := os.Rename(dest, aside); err != nil {
+		os.Remove(asideDir)
+		return nil, fmt.Errorf("error moving existing file '%s' aside: %w", dest, err)
+	}
+
+	if err := internal.MoveAll(src, dest); err != nil {
+		if restoreErr := os.Rename(aside, dest); restoreErr != nil {
+			return nil, fmt.Errorf("%w; additionally, the existing file couldn't be moved back from '%s': %w", err, aside, restoreErr)
+		}
+		os.Remove(asideDir)
+		return nil, err
+	}
+
+	return func() error {
+		return os.RemoveAll(asideDir)
+	}, nil
+}
+
+// restoreSubPath moves a file out of a trashed directory to dest. All errors
+// are returned as *RestoreError.
+func restoreSubPath(trashedFilePath, relative, dest string) error {
+	if !filepath.IsLocal(relative) || filepath.Clean(relative) == "." {
+		return &RestoreError{
+			Path: trashedFilePath,
+			Dest: dest,
+			Err:  fmt.Errorf("path '%s' isn't located inside of the trashed directory", relative),
+		}
+	}
+
+	src := filepath.Join(trashedFilePath, relative)
+	if err := restoreSubPathTo(src, dest); err != nil {
+		return &RestoreError{Path: src, Dest: dest, Err: err}
+	}
+	return nil
+}
+
+func restoreSubPathTo(src, dest string) error {
+	dest, err := filepath.Abs(dest)
+	if err != nil {
+		return fmt.Errorf("error retrieving absolute filepath: %w", err)
+	}
+
+	if _, err := os.Lstat(dest); err == nil {
+		return ErrAlreadyExists
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error checking whether file exists: %w", err)
+	}
+
+	if _, err := os.Lstat(src); err != nil {
+		return fmt.Errorf("error retrieving file info: %w", err)
+	}
+
+	// Usually the parent directory is missing, as it is part of the
+	// trashed directory.
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("error creating parent directories: %w", err)
+	}
+
+	return internal.MoveAll(src, dest)
+}
+
+// restoreParents restores all missing parent directories of path, that are
+// located in the trash. If multiple versions of a directory are trashed,
+// the newest one is restored. Parents that aren't in the trash are left
+// missing, unless a trashed directory further down has to be restored into
+// them, in which case they are created.
+func restoreParents(path string) error {
+	missing, err := missingParents(path)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	newest := make(map[string]TrashedFileInfo, len(missing))
+	for match, err := range query(context.Background(), QueryOptions{Search: missing}) {
+		if err != nil {
+			var entryErr *EntryError
+			if errors.As(err, &entryErr) {
+				continue
+			}
+			return err
+		}
+		if current, ok := newest[match.input]; !ok || match.info.DeletionDate().After(current.DeletionDate()) {
+			newest[match.input] = match.info
+		}
+	}
+
+	// Going from the top down, as restoring a directory might already
+	// restore some of the directories further down.
+	for _, dir := range missing {
+		trashed, ok := newest[dir]
+		if !ok {
+			continue
+		}
+		if _, err := os.Lstat(dir); err == nil {
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error checking whether directory exists: %w", err)
+		}
+
+		options := RestoreOptions{CreateParents: true}
+		if err := trashed.RestoreTo(dir, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// missingParents returns all missing parent directories of path, the
+// topmost one first.
+func missingParents(path string) ([]string, error) {
+	var missing []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("error checking whether directory exists: %w", err)
+		}
+
+		missing = append(missing, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	slices.Reverse(missing)
+	return missing, nil
+}
+
+// conflictPolicy returns the effective policy, respecting Force.
+func conflictPolicy(options RestoreOptions) ConflictPolicy {
+	if options.Force && options.OnConflict == ConflictFail {
+		return ConflictOverwrite
+	}
+	return options.OnConflict
+}
+
+// keepBothPath returns the first path in the form of `name (restored N).ext`
+// that doesn't exist yet. Directories don't have an extension.
+func keepBothPath(path string, isDir bool) (string, error) {
+	dir, name := filepath.Split(path)
+	var ext string
+	// Hidden files such as .bashrc aren't considered extensions.
+	if !isDir && filepath.Ext(name) != name {
+		ext = filepath.Ext(name)
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	for i := 1; ; i++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s (restored %d)%s", name, i, ext))
+		if _, err := os.Lstat(candidate); errors.Is(err, fs.ErrNotExist) {
+			return candidate, nil
+		} else if err != nil {
+			return "", fmt.Errorf("error checking whether file exists: %w", err)
+		}
+	}
+}
+
+// mergeDirectory moves the content of src into the existing directory dst.
+// Nested directories are merged recursively. Conflicting files are left in
+// src, in which case an error wrapping ErrAlreadyExists is returned for each
+// of them. src is removed once it is empty.
+func mergeDirectory(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("error reading directory '%s': %w", src, err)
+	}
+
+	var conflicts []error
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+
+		dstInfo, err := os.Lstat(dstPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			if err := internal.MoveAll(srcPath, dstPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error checking whether file exists: %w", err)
+		}
+
+		if entry.IsDir() && dstInfo.IsDir() {
+			if err := mergeDirectory(srcPath, dstPath); err != nil {
+				if !errors.Is(err, ErrAlreadyExists) {
+					return err
+				}
+				conflicts = append(conflicts, err)
+			}
+			continue
+		}
+
+		conflicts = append(conflicts, fmt.Errorf("%w: '%s'", ErrAlreadyExists, dstPath))
+	}
+
+	if len(conflicts) > 0 {
+		return errors.Join(conflicts...)
+	}
+
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("error removing merged directory '%s': %w", src, err)
+	}
+	return nil
+}
@@ -1,22 +1,24 @@
 package wastebasket
 
 import (
-	"encoding/binary"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"iter"
 	"os"
 	"os/user"
 	"path/filepath"
+	"slices"
 	"strings"
-	"syscall"
-	"time"
 	"unicode/utf16"
 	"unsafe"
 
+	"github.com/Bios-Marcel/wastebasket/v2/recyclebin"
 	"github.com/Bios-Marcel/wastebasket/v2/wastebasket_windows"
-	"github.com/gobwas/glob"
 
 	"golang.org/x/sys/windows"
-	"golang.org/x/text/encoding/unicode"
 )
 
 var (
@@ -91,7 +93,92 @@ func Trash(paths ...string) error {
 		existingPaths = append(existingPaths, path)
 	}
 
-	filesParameter, err := makeDoubleNullTerminatedLpstr(existingPaths...)
+	return shellTrash(existingPaths...)
+}
+
+// TrashWithOptions is the same as Trash, but allows configuring the error
+// handling and reports the result for each path. As the paths are passed to
+// the shell one by one, this is slower than Trash. The shell doesn't tell us
+// which names it uses inside of the recycle bin, so only the TrashDir is
+// reported.
+func TrashWithOptions(options TrashOptions, paths ...string) (*TrashReport, error) {
+	user, err := user.Current()
+	if err != nil {
+		return nil, fmt.Errorf("error querying SID of windows user: %w", err)
+	}
+
+	report := &TrashReport{Results: make([]TrashResult, 0, len(paths))}
+	var errs []error
+	for _, path := range paths {
+		result := TrashResult{Path: path}
+		if _, err := os.Stat(path); err != nil {
+			if !os.IsNotExist(err) {
+				result.Err = err
+			}
+		} else if absPath, err := filepath.Abs(path); err != nil {
+			result.Err = fmt.Errorf("error retrieving absolute filepath: %w", err)
+		} else if err := shellTrash(absPath); err != nil {
+			result.Err = err
+		} else {
+			result.TrashDir = fmt.Sprintf(`%s\$Recycle.Bin\%s`, filepath.VolumeName(absPath), user.Uid)
+		}
+		report.Results = append(report.Results, result)
+
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("error trashing '%s': %w", path, result.Err))
+			if !options.ContinueOnError {
+				break
+			}
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// TrashAndReturn is the same as Trash, but returns the entries created in
+// the recycle bin. These can be used to restore or delete the files again.
+// Since the shell doesn't tell us where it put the files, the recycle bin
+// is queried for the most recent entry of each trashed path.
+func TrashAndReturn(paths ...string) ([]TrashedFileInfo, error) {
+	report, trashErr := TrashWithOptions(TrashOptions{}, paths...)
+	if report == nil {
+		return nil, trashErr
+	}
+
+	var search []string
+	for _, result := range report.Results {
+		if result.TrashDir != "" {
+			search = append(search, result.Path)
+		}
+	}
+	if len(search) == 0 {
+		return nil, trashErr
+	}
+
+	queryResult, err := Query(QueryOptions{Search: search})
+	if err != nil {
+		return nil, errors.Join(trashErr, fmt.Errorf("error querying trashed files: %w", err))
+	}
+
+	infos := make([]TrashedFileInfo, 0, len(search))
+	for _, path := range search {
+		var newest TrashedFileInfo
+		for _, match := range queryResult.Matches[path] {
+			if newest == nil || match.DeletionDate().After(newest.DeletionDate()) {
+				newest = match
+			}
+		}
+		if newest != nil {
+			infos = append(infos, newest)
+		}
+	}
+
+	return infos, trashErr
+}
+
+// shellTrash moves the given, existing, paths into the recycle bin.
+func shellTrash(paths ...string) error {
+	filesParameter, err := makeDoubleNullTerminatedLpstr(paths...)
 	if err != nil {
 		return fmt.Errorf("error creating utf16ptr for passed path: %w", err)
 	}
@@ -135,9 +222,15 @@ const (
 
 // Empty clears the platforms trashbin.
 func Empty() error {
+	return emptyRecycleBin(nil)
+}
+
+// emptyRecycleBin clears the recycle bin of the given root path, such as
+// `C:\`. If the root path is nil, all recycle bins are cleared.
+func emptyRecycleBin(rootPath *uint16) error {
 	flags := SHERB_NOCONFIRMATION | SHERB_NOPROGRESSUI | SHERB_NOSOUND
 
-	ret, _, err := shEmptyRecycleBinW.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(nil)), uintptr(flags))
+	ret, _, err := shEmptyRecycleBinW.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(rootPath)), uintptr(flags))
 	if ret != 0 {
 		// Weird edge case, where windows reports that it couldnt load the DLL
 		// if the trash bin is empty.
@@ -151,190 +244,382 @@ func Empty() error {
 	return nil
 }
 
-// The info files have the following structure:
-// 8 Byte header
-// 8 Byte for file size
-// 8 Byte for deletion date
-// 4 Byte for path length
-// N Byte for path
+// emptyScope returns a function reporting whether a recycle bin is selected
+// by the options. If all recycle bins are selected, nil is returned.
+func emptyScope(options EmptyOptions) (func(trashDir string) bool, error) {
+	var volume string
+	switch {
+	case options.HomeOnly:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving home directory: %w", err)
+		}
+		volume = filepath.VolumeName(home)
+	case options.Mount != "":
+		mount, err := filepath.Abs(options.Mount)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving absolute filepath: %w", err)
+		}
+		volume = filepath.VolumeName(mount)
+		volumes, err := logicalDrives()
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(volumes, func(drive string) bool {
+			return strings.EqualFold(filepath.VolumeName(drive), volume)
+		}) || !strings.EqualFold(strings.TrimRight(mount, `\`), volume) {
+			return nil, fmt.Errorf("%w: '%s'", ErrUnknownMount, options.Mount)
+		}
+	default:
+		return nil, nil
+	}
 
-// https://stackoverflow.com/questions/6693^9004/windows-recycle-bin-information-file-binary-format
+	return func(trashDir string) bool {
+		return strings.EqualFold(filepath.VolumeName(trashDir), volume)
+	}, nil
+}
 
-func Query(options QueryOptions) (*QueryResult, error) {
-	if err := options.validate(); err != nil {
-		return nil, fmt.Errorf("error validating options: %w", err)
+// emptyScoped clears all recycle bins selected by inScope.
+func emptyScoped(inScope func(trashDir string) bool) error {
+	user, err := user.Current()
+	if err != nil {
+		return fmt.Errorf("error querying SID of windows user: %w", err)
 	}
 
-	user, err := user.Current()
+	volumes, err := logicalDrives()
 	if err != nil {
-		return nil, fmt.Errorf("error querying SID of windows user: %w", err)
+		return err
 	}
 
-	// We map the paths per volume, assuming that each volume only contains
-	// files from that volume. Additionally, we make all paths
-	// absolute, defaulting to the volume of the current working directory.
-	volumeMapping := make(map[string][][2]string)
-	var globCompiled glob.Glob
-	if options.Glob {
-		globString := options.Search[0]
-		globCompiled, err = glob.Compile(globString)
-		if err != nil {
-			return nil, fmt.Errorf("error compiling glob: %w", err)
+	for _, volume := range volumes {
+		if !inScope(fmt.Sprintf(`%s\$Recycle.Bin\%s`, filepath.VolumeName(volume), user.Uid)) {
+			continue
 		}
 
-		// FIXME Figure out what exactly counts as a logical drive and whether
-		// we need to potentially filter out network drives and such. Do network
-		// drives even support trashing?
-		volumes, err := windows.GetLogicalDriveStrings(0, nil)
+		rootPath, err := windows.UTF16PtrFromString(volume)
 		if err != nil {
-			return nil, fmt.Errorf("error retrieving logical drive strings: %w", err)
+			return fmt.Errorf("error converting string to utf16: %w", err)
 		}
-
-		a := make([]uint16, volumes)
-		windows.GetLogicalDriveStrings(volumes, &a[0])
-		s := string(utf16.Decode(a))
-		for _, volume := range strings.Split(strings.TrimRight(s, "\x00"), "\x00") {
-			volumeMapping[volume] = nil
+		if err := emptyRecycleBin(rootPath); err != nil {
+			return fmt.Errorf("error clearing recycle bin of '%s': %w", volume, err)
 		}
-	} else {
-		for _, path := range options.Search {
-			absPath, err := filepath.Abs(path)
-			if err != nil {
-				return nil, fmt.Errorf("error retrieving absolute filepath: %w", err)
-			}
+	}
 
-			volumeName := filepath.VolumeName(absPath)
-			volumeMapping[volumeName] = append(volumeMapping[volumeName], [...]string{absPath, path})
-		}
+	return nil
+}
+
+// emptyWithReport deletes all files of the recycle bins selected by inScope
+// one by one, as the shell doesn't tell us what it deleted. If inScope is
+// nil, all recycle bins are cleared.
+func emptyWithReport(inScope func(trashDir string) bool) (*EmptyReport, error) {
+	return deleteMatches(EmptyOptions{}, inScope, true)
+}
+
+// trashDirSizer returns a function measuring the files of the given recycle
+// bin. The sizes are stored in the info files, so there's nothing to share
+// between the files.
+func trashDirSizer(trashDir string) func(TrashedFileInfo) (int64, error) {
+	return func(info TrashedFileInfo) (int64, error) {
+		return info.Size()
 	}
+}
+
+// isReadOnly checks whether the error was caused by a write protected
+// volume.
+func isReadOnly(err error) bool {
+	return errors.Is(err, windows.ERROR_WRITE_PROTECT)
+}
 
-	result := &QueryResult{
-		Matches: make(map[string][]TrashedFileInfo),
+// logicalDrives returns the root paths of all logical drives, such as `C:\`.
+func logicalDrives() ([]string, error) {
+	// FIXME Figure out what exactly counts as a logical drive and whether
+	// we need to potentially filter out network drives and such. Do network
+	// drives even support trashing?
+	volumes, err := windows.GetLogicalDriveStrings(0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving logical drive strings: %w", err)
 	}
-	pathDecoder := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()
-	for volume, paths := range volumeMapping {
-		rootTrash := fmt.Sprintf(`%s\$Recycle.Bin\%s`, volume, user.Uid)
 
-		infoFiles, err := filepath.Glob(rootTrash + `\$I*`)
+	a := make([]uint16, volumes)
+	windows.GetLogicalDriveStrings(volumes, &a[0])
+	s := string(utf16.Decode(a))
+	return strings.Split(strings.TrimRight(s, "\x00"), "\x00"), nil
+}
+
+// The info files are described in the recyclebin package.
+
+// query streams all matches of all recycle bins. It stops on the first
+// error, except for *EntryError, which are yielded, but don't stop the query.
+func query(ctx context.Context, options QueryOptions) iter.Seq2[queryMatch, error] {
+	return func(yield func(queryMatch, error) bool) {
+		if err := options.validate(); err != nil {
+			yield(queryMatch{}, fmt.Errorf("error validating options: %w", err))
+			return
+		}
+
+		filter, err := newQueryFilter(options)
 		if err != nil {
-			return nil, fmt.Errorf("error looking up info files: %w", err)
+			yield(queryMatch{}, fmt.Errorf("error creating filter: %w", err))
+			return
 		}
 
-	INFO_LOOP:
-		for _, infoFile := range infoFiles {
-			bytes, err := os.ReadFile(infoFile)
+		user, err := user.Current()
+		if err != nil {
+			yield(queryMatch{}, fmt.Errorf("error querying SID of windows user: %w", err))
+			return
+		}
+
+		// We map the paths per volume, assuming that each volume only contains
+		// files from that volume. Additionally, we make all paths
+		// absolute, defaulting to the volume of the current working directory.
+		volumeMapping := make(map[string][][2]string)
+		var patternMatcher func(string) (string, bool)
+		if options.matchMode() != MatchExact {
+			patternMatcher, err = newPatternMatcher(options)
 			if err != nil {
-				err := fmt.Errorf("error reading info file: %w", err)
-				return nil, err
+				yield(queryMatch{}, fmt.Errorf("error creating matcher: %w", err))
+				return
 			}
+		}
 
-			trashedFile := fmt.Sprintf("%s\\$R%s", rootTrash, strings.TrimPrefix(filepath.Base(infoFile), "$I"))
-			// Windows seems to keep the metadata files on restoration
-			// Until I've figured out why, i'll ignore these files.
-			// If the error is non-nil, we will ignore it and continue. Since
-			// the stat call to this file, is not directly important.
-			if _, err := os.Stat(trashedFile); os.IsNotExist(err) {
-				continue INFO_LOOP
+		// Patterns could match files on any volume.
+		if patternMatcher != nil || options.All {
+			volumes, err := logicalDrives()
+			if err != nil {
+				yield(queryMatch{}, err)
+				return
+			}
+			for _, volume := range volumes {
+				volumeMapping[volume] = nil
 			}
+		} else {
+			for _, path := range options.Search {
+				absPath, err := filepath.Abs(path)
+				if err != nil {
+					yield(queryMatch{}, fmt.Errorf("error retrieving absolute filepath: %w", err))
+					return
+				}
 
-			// -2 to ignore nullbyte in the end
-			var originalFilepath string
-			if pathBytes, err := pathDecoder.Bytes(bytes[28 : len(bytes)-2]); err != nil {
-				err := fmt.Errorf("error decoding path: %w", err)
-				return nil, err
-			} else {
-				originalFilepath = string(pathBytes)
+				volumeName := filepath.VolumeName(absPath)
+				volumeMapping[volumeName] = append(volumeMapping[volumeName], [...]string{absPath, path})
 			}
+		}
 
-			// Since globs and paths are mutually exclusive, at best one of those
-			// loops will match and we don't need to run both.
-			if globCompiled != nil {
-				if globCompiled.Match(originalFilepath) {
-					info := createTrashedFile(infoFile, trashedFile, originalFilepath, bytes)
-					result.Matches[options.Search[0]] = append(result.Matches[options.Search[0]], info)
-				}
-				continue INFO_LOOP
+		for volume, paths := range volumeMapping {
+			rootTrash := fmt.Sprintf(`%s\$Recycle.Bin\%s`, volume, user.Uid)
+
+			infoFiles, err := filepath.Glob(rootTrash + `\$I*`)
+			if err != nil {
+				yield(queryMatch{}, fmt.Errorf("error looking up info files: %w", err))
+				return
 			}
-			for _, path := range paths {
-				if path[0] == originalFilepath {
-					info := createTrashedFile(infoFile, trashedFile, originalFilepath, bytes)
-					result.Matches[path[1]] = append(result.Matches[path[1]], info)
+
+		INFO_LOOP:
+			for _, infoFile := range infoFiles {
+				trashedFile := fmt.Sprintf("%s\\$R%s", rootTrash, strings.TrimPrefix(filepath.Base(infoFile), "$I"))
+				// Windows seems to keep the metadata files on restoration
+				// Until I've figured out why, i'll ignore these files.
+				// If the error is non-nil, we will ignore it and continue. Since
+				// the stat call to this file, is not directly important.
+				if _, err := os.Stat(trashedFile); os.IsNotExist(err) {
+					continue INFO_LOOP
+				}
+
+				if err := ctx.Err(); err != nil {
+					yield(queryMatch{}, err)
+					return
+				}
+
+				info, err := readInfoFile(infoFile)
+				if err != nil {
+					if !yield(queryMatch{}, &EntryError{InfoPath: infoFile, Err: err}) {
+						return
+					}
+					continue INFO_LOOP
+				}
+				originalFilepath := info.Path
+
+				var input string
+				switch {
+				case options.All:
+					input = rootTrash
+				case patternMatcher != nil:
+					var matches bool
+					if input, matches = patternMatcher(originalFilepath); !matches {
+						continue INFO_LOOP
+					}
+				default:
+					index := slices.IndexFunc(paths, func(path [2]string) bool {
+						if options.CaseInsensitive {
+							return strings.EqualFold(path[0], originalFilepath)
+						}
+						return path[0] == originalFilepath
+					})
+					if index == -1 {
+						continue INFO_LOOP
+					}
+					input = paths[index][1]
+				}
+
+				if !filter.matchesMetadata(originalFilepath, info.DeletionDate) {
 					continue INFO_LOOP
 				}
+				if filter.needsFile() {
+					matches, err := matchesTrashedFile(filter, trashedFile, info)
+					if err != nil {
+						if !yield(queryMatch{}, &EntryError{InfoPath: infoFile, Err: err}) {
+							return
+						}
+						continue INFO_LOOP
+					}
+					if !matches {
+						continue INFO_LOOP
+					}
+				}
+
+				match := queryMatch{input: input, trashDir: rootTrash, info: createTrashedFile(infoFile, trashedFile, info)}
+				if !yield(match, nil) {
+					return
+				}
 			}
 		}
 	}
+}
 
-	return result, nil
+// matchesTrashedFile applies the filters requiring access to the trashed
+// file. The size is taken from the info file, as it already contains the
+// size of directories including their content.
+func matchesTrashedFile(filter *queryFilter, trashedFile string, info recyclebin.Info) (bool, error) {
+	fileInfo, err := os.Lstat(trashedFile)
+	if err != nil {
+		return false, fmt.Errorf("error retrieving file info: %w", err)
+	}
+	return filter.matchesFile(fileInfo.Mode(), func() (int64, error) {
+		return int64(info.Size), nil
+	})
 }
 
-func createTrashedFile(infoFile, trashedFile, originalFilepath string, infoData []byte) *wastebasket_windows.TrashedFileInfo {
-	// According to an SO article, the info file can contain
-	// garbage bytes in the beginning, but seemingly, they seem
-	// to be little endian notation BOM bytes. While I haven't
-	// encountered these and can't confirm that they
-	// exist, guarding against this shouldn't hurt.
-	var byteOffset int
-	for index, b := range infoData {
-		// Start of header found
-		if b == 0x02 {
-			byteOffset = index
-			break
+// lookup reads a single entry, after making sure that the info file belongs
+// to the recycle bin of the current user.
+func lookup(infoFile string) (TrashedFileInfo, error) {
+	if infoFile != filepath.Clean(infoFile) || !strings.HasPrefix(filepath.Base(infoFile), "$I") {
+		return nil, ErrInvalidID
+	}
+
+	user, err := user.Current()
+	if err != nil {
+		return nil, fmt.Errorf("error querying SID of windows user: %w", err)
+	}
+	rootTrash := fmt.Sprintf(`%s\$Recycle.Bin\%s`, filepath.VolumeName(infoFile), user.Uid)
+	if !strings.EqualFold(filepath.Dir(infoFile), rootTrash) {
+		return nil, ErrInvalidID
+	}
+
+	trashedFile := filepath.Join(filepath.Dir(infoFile), "$R"+strings.TrimPrefix(filepath.Base(infoFile), "$I"))
+	if _, err := os.Lstat(trashedFile); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
 		}
+		return nil, fmt.Errorf("error retrieving file info: %w", err)
 	}
 
-	fileSize := binary.LittleEndian.Uint64(infoData[byteOffset+8 : byteOffset+16])
-	deletionTime := syscall.Filetime{
-		LowDateTime:  binary.LittleEndian.Uint32(infoData[byteOffset+16 : byteOffset+20]),
-		HighDateTime: binary.LittleEndian.Uint32(infoData[byteOffset+20 : byteOffset+24]),
+	info, err := readInfoFile(infoFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, &EntryError{InfoPath: infoFile, Err: err}
+	}
+
+	return createTrashedFile(infoFile, trashedFile, info), nil
+}
+
+func readInfoFile(infoFile string) (recyclebin.Info, error) {
+	handle, err := os.Open(infoFile)
+	if err != nil {
+		return recyclebin.Info{}, fmt.Errorf("error opening info file: %w", err)
 	}
+	defer handle.Close()
 
-	recoverFunc := createRecover(infoFile, trashedFile, originalFilepath)
+	return recyclebin.Parse(handle)
+}
+
+func createTrashedFile(infoFile, trashedFile string, info recyclebin.Info) *wastebasket_windows.TrashedFileInfo {
+	recoverFunc := createRecover(infoFile, trashedFile)
 	deleteFunc := createDelete(infoFile, trashedFile)
+	restorePathFunc := createRestorePath(infoFile, trashedFile)
 	return wastebasket_windows.NewTrashedFileInfo(
-		fileSize,
+		info.Size,
 		infoFile,
-		originalFilepath,
-		time.Unix(0, deletionTime.Nanoseconds()),
+		trashedFile,
+		info.Path,
+		info.DeletionDate,
 		recoverFunc,
 		deleteFunc,
+		restorePathFunc,
 	)
 }
 
 func createDelete(infoFile, trashedFile string) func() error {
 	return func() error {
-		if err := os.Remove(infoFile); err != nil {
-			return fmt.Errorf("error removing info file: %w", err)
+		// The trashed file is removed first, so that a partial failure
+		// doesn't leave behind data without metadata.
+		if err := os.RemoveAll(trashedFile); err != nil {
+			return fmt.Errorf("error removing trashed file: %w", err)
 		}
 
-		if err := os.Remove(trashedFile); err != nil {
-			return fmt.Errorf("error removing trashed file: %w", err)
+		if err := os.Remove(infoFile); err != nil {
+			return fmt.Errorf("error removing info file: %w", err)
 		}
 
 		return nil
 	}
 }
 
-func createRecover(infoFile, trashedFile, originalFile string) func(force bool) error {
-	return func(force bool) error {
-		if !force {
-			info, err := os.Stat(originalFile)
-			if err != nil && !os.IsNotExist(err) {
-				return fmt.Errorf("error checking whether file exists: %w", err)
+func createRecover(infoFile, trashedFile string) func(dest string, options RestoreOptions) error {
+	return func(dest string, options RestoreOptions) error {
+		return restoreFile(infoFile, trashedFile, dest, options)
+	}
+}
+
+// createRestorePath restores a single file out of a trashed directory. As
+// the info file contains the size of the whole directory, the size is
+// reduced by the size of the restored file.
+func createRestorePath(infoFile, trashedFile string) func(relative, dest string) error {
+	return func(relative, dest string) error {
+		var size uint64
+		// If the file is invalid, restoreSubPath fails anyway.
+		_ = filepath.WalkDir(filepath.Join(trashedFile, relative), func(_ string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
 			}
-			if info != nil {
-				return ErrAlreadyExists
+			if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+				size += uint64(info.Size())
 			}
-		}
-		err := os.Rename(trashedFile, originalFile)
-		if err != nil {
-			return fmt.Errorf("error restoring file: %w", err)
-		}
+			return nil
+		})
 
-		if err := os.Remove(infoFile); err != nil {
-			return fmt.Errorf("error removing info file: %w", err)
+		if err := restoreSubPath(trashedFile, relative, dest); err != nil {
+			return err
 		}
 
+		// The size is purely informational, therefore failing to update it
+		// is ignored.
+		_ = updateInfoFileSize(infoFile, size)
 		return nil
 	}
 }
+
+func updateInfoFileSize(infoFile string, removed uint64) error {
+	info, err := readInfoFile(infoFile)
+	if err != nil {
+		return err
+	}
+	info.Size -= min(removed, info.Size)
+
+	var buffer bytes.Buffer
+	if _, err := info.WriteTo(&buffer); err != nil {
+		return err
+	}
+	return os.WriteFile(infoFile, buffer.Bytes(), 0o600)
+}
@@ -2,32 +2,41 @@ package wastebasket_windows
 
 import (
 	"fmt"
-	"hash/fnv"
+	"io/fs"
+	"os"
 	"time"
+
+	"github.com/Bios-Marcel/wastebasket/v2/internal"
+	"github.com/Bios-Marcel/wastebasket/v2/recyclebin"
 )
 
 type TrashedFileInfo struct {
-	fileSize     uint64
-	infoPath     string
-	originalPath string
-	deletionDate time.Time
-	restoreFunc  func(force bool) error
-	deleteFunc   func() error
+	fileSize        uint64
+	infoPath        string
+	currentPath     string
+	originalPath    string
+	deletionDate    time.Time
+	restoreFunc     func(dest string, options internal.RestoreOptions) error
+	deleteFunc      func() error
+	restorePathFunc func(relative, dest string) error
 }
 
 func NewTrashedFileInfo(
 	fileSize uint64,
-	infoPath, originalPath string, deletionDate time.Time,
-	restore func(force bool) error,
+	infoPath, currentPath, originalPath string, deletionDate time.Time,
+	restore func(dest string, options internal.RestoreOptions) error,
 	deleteFunc func() error,
+	restorePathFunc func(relative, dest string) error,
 ) *TrashedFileInfo {
 	return &TrashedFileInfo{
-		fileSize:     fileSize,
-		infoPath:     infoPath,
-		originalPath: originalPath,
-		deletionDate: deletionDate,
-		restoreFunc:  restore,
-		deleteFunc:   deleteFunc,
+		fileSize:        fileSize,
+		infoPath:        infoPath,
+		currentPath:     currentPath,
+		originalPath:    originalPath,
+		deletionDate:    deletionDate,
+		restoreFunc:     restore,
+		deleteFunc:      deleteFunc,
+		restorePathFunc: restorePathFunc,
 	}
 }
 
@@ -35,10 +44,45 @@ func (t TrashedFileInfo) FileSize() uint64 {
 	return t.fileSize
 }
 
+// Size is the same as FileSize, but satisfies the cross platform interface.
+// For directories, this includes the size of their content. Unlike FileSize,
+// the size is read from the info file on each call, so it reflects files
+// restored via RestorePath.
+func (t TrashedFileInfo) Size() (int64, error) {
+	handle, err := os.Open(t.infoPath)
+	if err != nil {
+		return 0, fmt.Errorf("error opening info file: %w", err)
+	}
+	defer handle.Close()
+
+	info, err := recyclebin.Parse(handle)
+	if err != nil {
+		return 0, fmt.Errorf("error reading info file: %w", err)
+	}
+	return int64(info.Size), nil
+}
+
+// InfoPath is the path of the $I file, containing information about the
+// trashed file.
+func (t TrashedFileInfo) InfoPath() string {
+	return t.infoPath
+}
+
+// CurrentPath is the path of the $R file, which is the trashed file itself.
+func (t TrashedFileInfo) CurrentPath() string {
+	return t.currentPath
+}
+
+// Stat returns information about the file inside of the recycle bin.
+// Symlinks aren't followed.
+func (t TrashedFileInfo) Stat() (fs.FileInfo, error) {
+	return os.Lstat(t.currentPath)
+}
+
+// UniqueIdentifier encodes the path of the info file, which can be decoded
+// again in order to look up the file.
 func (t TrashedFileInfo) UniqueIdentifier() string {
-	hash := fnv.New64()
-	hash.Write([]byte(t.infoPath))
-	return fmt.Sprintf("%x", hash.Sum(nil))
+	return internal.EncodeID(t.infoPath)
 }
 
 func (t TrashedFileInfo) OriginalPath() string {
@@ -50,9 +94,30 @@ func (t TrashedFileInfo) DeletionDate() time.Time {
 }
 
 func (t TrashedFileInfo) Restore(force bool) error {
-	return t.restoreFunc(force)
+	return t.restoreFunc(t.originalPath, internal.RestoreOptions{Force: force})
+}
+
+// RestoreTo will attempt restoring the file to the given destination.
+func (t TrashedFileInfo) RestoreTo(dest string, options internal.RestoreOptions) error {
+	return t.restoreFunc(dest, options)
 }
 
 func (t TrashedFileInfo) Delete() error {
 	return t.deleteFunc()
 }
+
+// RestorePath restores a single file out of a trashed directory, leaving the
+// directory itself in the recycle bin.
+func (t TrashedFileInfo) RestorePath(relative, dest string) error {
+	return t.restorePathFunc(relative, dest)
+}
+
+// Open opens the file inside of the recycle bin for reading.
+func (t TrashedFileInfo) Open() (fs.File, error) {
+	return os.Open(t.currentPath)
+}
+
+// FS returns a filesystem rooted at the trashed directory.
+func (t TrashedFileInfo) FS() fs.FS {
+	return os.DirFS(t.currentPath)
+}